@@ -0,0 +1,224 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Aligns the English words of a verse with the strongs numbers of its
+// translation map line.  A map line gives the position of the English words
+// (from 1) for each strongs number:
+//
+//	01=<3972>           word 1 translates 3972
+//	12+13=<2596>        words 12 and 13 together translate 2596
+//	03+07=<3361>        words 3 and 7 translate 3361
+//	15=<1161>+<2532>    word 15 translates both 1161 and 2532
+//
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// alignedChunk is one or more English words and the strongs numbers they
+// translate.  Words that translate nothing are in chunks of their own, so
+// every word of the verse is in a chunk.
+type alignedChunk struct {
+	English string
+	Strongs []string
+
+	// Continued is true if the words finish a mapping that started earlier
+	// in the verse.  i.e. word 7 of "03+07"
+	Continued bool
+
+	// Morphology describes the Greek or Hebrew word behind each strongs
+	// number, if it was asked for.  See addMorphology.
+	Morphology []string
+}
+
+// verseAlignment is the alignment of one verse
+type verseAlignment struct {
+	VerseRef string
+	Chunks   []alignedChunk
+
+	// Unaligned explains each mapping that could not be matched to the words
+	Unaligned []string
+}
+
+// verseWords splits verse text into words the way the translation map file
+// numbers them.  Punctuation on its own, like a dash between spaces, is kept
+// with the word before it rather than counted as a word.
+func verseWords(text string) []string {
+	var words []string
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, isWordRune) < 0 && len(words) > 0 {
+			words[len(words)-1] += " " + field
+			continue
+		}
+		words = append(words, field)
+	}
+	return words
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// alignVerse matches the words of the verse text with the mappings of the
+// translation map line.  Words without a mapping and mappings of words past
+// the end of the text are noted in Unaligned.
+func alignVerse(verseRef, text, mapLine string) verseAlignment {
+	alignment := verseAlignment{VerseRef: verseRef}
+	words := verseWords(text)
+	mappings := parseWordMappings(verseRef, mapLine)
+
+	// The strongs numbers of each word and the mapping it is part of
+	strongs := make([][]string, len(words)+1)
+	group := make([]int, len(words)+1)
+	for i := range group {
+		group[i] = -1
+	}
+	for m, mapping := range mappings {
+		var outside []string
+		for _, position := range mapping.Positions {
+			if position < 1 || position > len(words) {
+				outside = append(outside, strconv.Itoa(position))
+				continue
+			}
+			for _, number := range mapping.Strongs {
+				if !containsString(strongs[position], number) {
+					strongs[position] = append(strongs[position], number)
+				}
+			}
+			if group[position] < 0 {
+				group[position] = m
+			}
+		}
+		if len(outside) > 0 {
+			alignment.Unaligned = append(alignment.Unaligned,
+				fmt.Sprintf("%s: word %s of %d words", strings.Join(mapping.Strongs, " "),
+					strings.Join(outside, "+"), len(words)))
+		}
+	}
+
+	// Build the chunks, ending one at the last word of each mapping or run
+	// of words without a mapping
+	var english []string
+	var unmapped []string
+	started := map[int]bool{}
+	for position := 1; position <= len(words); position++ {
+		english = append(english, words[position-1])
+		m := group[position]
+		if m < 0 {
+			unmapped = append(unmapped, fmt.Sprintf("%d %q", position, words[position-1]))
+		}
+		// Keep the next word in this chunk if it is part of the same mapping
+		if position < len(words) && group[position+1] == m {
+			continue
+		}
+		chunk := alignedChunk{English: strings.Join(english, " ")}
+		if m >= 0 {
+			chunk.Strongs = strongs[position]
+			chunk.Continued = started[m]
+			started[m] = true
+		}
+		alignment.Chunks = append(alignment.Chunks, chunk)
+		english = nil
+	}
+
+	switch len(unmapped) {
+	case 0:
+	case 1:
+		alignment.Unaligned = append(alignment.Unaligned,
+			"word "+unmapped[0]+" because it has no strongs number")
+	default:
+		alignment.Unaligned = append(alignment.Unaligned,
+			"words "+strings.Join(unmapped, ", ")+" because they have no strongs number")
+	}
+	return alignment
+}
+
+// printAlignment prints the English words right aligned next to their
+// strongs numbers, with the romanized lemma and a short gloss of each number
+// and the morphology of the word if it was added.  Words that translate more
+// than one number get a line for each.
+func printAlignment(alignment verseAlignment) {
+	// fmt pads by runes, so the widths are counted in runes too
+	englishWidth, strongsWidth, lemmaWidth := 0, 0, 0
+	continued := false
+	for _, chunk := range alignment.Chunks {
+		englishWidth = maxWidth(englishWidth, chunk.English)
+		continued = continued || chunk.Continued
+		for _, number := range chunk.Strongs {
+			strongsWidth = maxWidth(strongsWidth, number)
+			if entry, err := lookupStrongs(number); err == nil {
+				lemmaWidth = maxWidth(lemmaWidth, entry.Lemma)
+			}
+		}
+	}
+
+	for _, chunk := range alignment.Chunks {
+		english := chunk.English
+		if len(chunk.Strongs) == 0 {
+			fmt.Printf("%*s\n", englishWidth, english)
+			continue
+		}
+		for i, number := range chunk.Strongs {
+			fmt.Printf("%*s ", englishWidth, english)
+			english = ""
+			if chunk.Continued {
+				theme.Muted.Print("... ")
+			} else if continued {
+				fmt.Print("    ")
+			}
+
+			if entry, err := lookupStrongs(number); err == nil {
+				fmt.Printf("%-*s %-*s", strongsWidth, number, lemmaWidth, entry.Lemma)
+				theme.Muted.Printf(" - %s\n", shortGloss(entry))
+			} else {
+				fmt.Println(number)
+			}
+
+			// The morphology goes under the strongs number
+			if i < len(chunk.Morphology) && len(chunk.Morphology[i]) > 0 {
+				indent := englishWidth + 1
+				if continued {
+					indent += 4
+				}
+				theme.Hint.Printf("%*s%s\n", indent, "", chunk.Morphology[i])
+			}
+		}
+	}
+
+	for _, problem := range alignment.Unaligned {
+		theme.Muted.Printf("Unable to align %s\n", problem)
+	}
+}
+
+// maxWidth returns the larger of width and the number of runes in text
+func maxWidth(width int, text string) int {
+	if n := utf8.RuneCountInString(text); n > width {
+		return n
+	}
+	return width
+}
@@ -0,0 +1,240 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// testStrongsGreek has entries in the format of strongsgreek.dat
+const testStrongsGreek = `$$T0003956
+\03956\
+ 3956  pas  pas
+
+ including all the forms of declension; apparently a primary word; all, any, every, the whole:--all (manner of, means), alway(-s), any (one).
+$$T0004982
+\04982\
+ 4982  sozo  sode'-zo
+
+ from a primary sos (contraction for obsolete saos, "safe"); to save:--heal, preserve, save (self), do well, be (make) whole.
+`
+
+func TestMaxWidthCountsRunes(t *testing.T) {
+	if width := maxWidth(0, "ἀγάπη"); width != 5 {
+		t.Errorf("maxWidth(0, \"ἀγάπη\") = %d, want 5", width)
+	}
+	if width := maxWidth(7, "pas"); width != 7 {
+		t.Errorf("maxWidth(7, \"pas\") = %d, want 7", width)
+	}
+}
+
+func TestPrintAlignmentColumns(t *testing.T) {
+	useDataDir(t)
+	if err := ioutil.WriteFile(strongsGreekFile, []byte(testStrongsGreek), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureOutput(t, func() {
+		printAlignment(verseAlignment{VerseRef: "Rom 8:28", Chunks: []alignedChunk{
+			{English: "that all", Strongs: []string{"g3956"}},
+			{English: "café", Strongs: []string{"g4982"}},
+		}})
+	})
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	want := []string{
+		"that all g3956 pas  - all (manner of, means), alway(-s), any...",
+		"    café g4982 sozo - heal, preserve, save (self), do well,...",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("printAlignment printed %q", out)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d is %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+
+	// The glosses line up even though "é" is two bytes
+	if a, b := utf8.RuneCountInString(lines[0][:strings.Index(lines[0], " - ")]),
+		utf8.RuneCountInString(lines[1][:strings.Index(lines[1], " - ")]); a != b {
+		t.Errorf("the glosses start at runes %d and %d", a, b)
+	}
+}
+
+func TestAlignVerse(t *testing.T) {
+	tests := []struct {
+		name      string
+		verseRef  string
+		text      string
+		mapLine   string
+		chunks    []alignedChunk
+		unaligned []string
+	}{
+		{
+			name:     "one word each",
+			verseRef: "Rom 8:28",
+			text:     "And we know",
+			mapLine:  "01=<2532> 02=<1492> 03=<1492>",
+			chunks: []alignedChunk{
+				{English: "And", Strongs: []string{"g2532"}},
+				{English: "we", Strongs: []string{"g1492"}},
+				{English: "know", Strongs: []string{"g1492"}},
+			},
+		},
+		{
+			name:     "multi-word span",
+			verseRef: "Jhn 3:16",
+			text:     "For God so loved",
+			mapLine:  "01=<1063> 02=<2316> 03+04=<3779>",
+			chunks: []alignedChunk{
+				{English: "For", Strongs: []string{"g1063"}},
+				{English: "God", Strongs: []string{"g2316"}},
+				{English: "so loved", Strongs: []string{"g3779"}},
+			},
+		},
+		{
+			name:     "non-contiguous mapping",
+			verseRef: "Rom 6:2",
+			text:     "By no means will die",
+			mapLine:  "01+04=<3361> 02+03=<1096> 05=<599>",
+			chunks: []alignedChunk{
+				{English: "By", Strongs: []string{"g3361"}},
+				{English: "no means", Strongs: []string{"g1096"}},
+				{English: "will", Strongs: []string{"g3361"}, Continued: true},
+				{English: "die", Strongs: []string{"g599"}},
+			},
+		},
+		{
+			name:     "one word for two numbers",
+			verseRef: "Rom 8:28",
+			text:     "all things",
+			mapLine:  "01=<3956>+<4903> 02=<3956>",
+			chunks: []alignedChunk{
+				{English: "all", Strongs: []string{"g3956", "g4903"}},
+				{English: "things", Strongs: []string{"g3956"}},
+			},
+		},
+		{
+			name:     "punctuation",
+			verseRef: "Gen 1:1",
+			text:     "In the beginning, God — created.",
+			mapLine:  "01+02+03=<7225> 04=<430> 05=<1254>",
+			chunks: []alignedChunk{
+				{English: "In the beginning,", Strongs: []string{"h7225"}},
+				{English: "God —", Strongs: []string{"h430"}},
+				{English: "created.", Strongs: []string{"h1254"}},
+			},
+		},
+		{
+			name:     "unmapped words",
+			verseRef: "Rom 8:28",
+			text:     "And we know that",
+			mapLine:  "01=<2532> 03=<1492>",
+			chunks: []alignedChunk{
+				{English: "And", Strongs: []string{"g2532"}},
+				{English: "we"},
+				{English: "know", Strongs: []string{"g1492"}},
+				{English: "that"},
+			},
+			unaligned: []string{`words 2 "we", 4 "that" because they have no strongs number`},
+		},
+		{
+			name:     "mapping past the text",
+			verseRef: "Rom 8:28",
+			text:     "And we",
+			mapLine:  "01=<2532> 02=<1492> 03=<3754>",
+			chunks: []alignedChunk{
+				{English: "And", Strongs: []string{"g2532"}},
+				{English: "we", Strongs: []string{"g1492"}},
+			},
+			unaligned: []string{"g3754: word 3 of 2 words"},
+		},
+	}
+	for _, test := range tests {
+		alignment := alignVerse(test.verseRef, test.text, test.mapLine)
+		if !reflect.DeepEqual(alignment.Chunks, test.chunks) {
+			t.Errorf("%s: chunks are %+v, want %+v", test.name, alignment.Chunks, test.chunks)
+		}
+		if !reflect.DeepEqual(alignment.Unaligned, test.unaligned) {
+			t.Errorf("%s: unaligned is %q, want %q", test.name, alignment.Unaligned, test.unaligned)
+		}
+	}
+}
+
+func TestAlignVerses(t *testing.T) {
+	dir := useDataDir(t)
+	savedIndex := translationIdx
+	translationIdx = nil
+	defer func() { translationIdx = savedIndex }()
+	savedProvider := textProvider
+	textProvider = newLocalProvider(filepath.Join(dir, "bible-text.txt"))
+	defer func() { textProvider = savedProvider }()
+
+	if err := WriteLinesAtomic(translationMapFile, []string{
+		"$Rom 8:28\t01=<2532> 03=<1492>",
+		"$Rom 8:29\t01=<3754> 02=<3739>",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteLinesAtomic(filepath.Join(dir, "bible-text.txt"), []string{
+		"# KJV",
+		"Rom 8:28\tAnd we know",
+		"Rom 8:29\tFor whom",
+		"Rom 8:30\tMoreover whom",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	refs, err := ParseReferences("Rom 8:28-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alignments, err := alignVerses(refs[0].Verses())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alignments) != 3 {
+		t.Fatalf("alignVerses returned %d alignments, want 3", len(alignments))
+	}
+	want := []verseAlignment{
+		{VerseRef: "Rom 8:28", Chunks: []alignedChunk{
+			{English: "And", Strongs: []string{"g2532"}},
+			{English: "we"},
+			{English: "know", Strongs: []string{"g1492"}},
+		}, Unaligned: []string{`word 2 "we" because it has no strongs number`}},
+		{VerseRef: "Rom 8:29", Chunks: []alignedChunk{
+			{English: "For", Strongs: []string{"g3754"}},
+			{English: "whom", Strongs: []string{"g3739"}},
+		}},
+		{VerseRef: "Rom 8:30", Unaligned: []string{"the verse because it has no translation map line"}},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(alignments[i], want[i]) {
+			t.Errorf("alignment %d is %+v, want %+v", i+1, alignments[i], want[i])
+		}
+	}
+}
@@ -23,9 +23,8 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"strings"
-
-	"github.com/gookit/color"
 )
 
 //
@@ -45,8 +44,6 @@ const (
 var (
 	bookNameMap = map[string]Book{}
 
-	apiToken = "9f29b9475bd3e4b05765c06741cf4c094eef8a8a"
-
 	categoryAliases = map[string][]string{
 		"history":      []string{"history", "hist", "historical"},
 		"poetry":       []string{"poetry", "poet"},
@@ -77,6 +74,32 @@ type Book struct {
 	Aliases         []string
 }
 
+// Number returns the position of the book in the Bible, from 1 (Genesis)
+// to 66 (Revelation)
+func (book Book) Number() int {
+	for i := range books {
+		if books[i].TranslationName == book.TranslationName {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Chapters returns the number of verses in each chapter of the book
+func (book Book) Chapters() []int {
+	return versification[book.TranslationName]
+}
+
+// VerseCount returns the number of verses in the chapter, or zero if the
+// book has no such chapter
+func (book Book) VerseCount(chapter int) int {
+	chapters := book.Chapters()
+	if chapter < 1 || chapter > len(chapters) {
+		return 0
+	}
+	return chapters[chapter-1]
+}
+
 // BookCategory is the classification type of the book
 type BookCategory int
 
@@ -113,6 +136,8 @@ func init() {
 	for _, book := range books {
 		fullNameLower := strings.ToLower(book.FullName)
 		bookNameMap[fullNameLower] = book
+		bookNameMap[strings.ToLower(book.TranslationName)] = book
+		bookNameMap[strings.ReplaceAll(fullNameLower, " ", "")] = book
 		for _, aliasName := range book.Aliases {
 			bookNameMap[aliasName] = book
 			filters[aliasName] = append(filters[aliasName], book.TranslationName)
@@ -138,74 +163,74 @@ func init() {
 
 // books is used for the translate command.
 var books = []Book{
-	Book{"Genesis", "Gen", oldTestament, law, []string{"gen"}},
-	Book{"Exodus", "Exo", oldTestament, law, []string{"ex", "exo"}},
-	Book{"Leviticus", "Lev", oldTestament, law, []string{"lev"}},
-	Book{"Numbers", "Num", oldTestament, law, []string{"nu", "num", "numb"}},
-	Book{"Deuteronomy", "Deu", oldTestament, law, []string{"deu", "deut"}},
+	Book{"Genesis", "Gen", oldTestament, law, []string{"gen", "ge", "gn"}},
+	Book{"Exodus", "Exo", oldTestament, law, []string{"ex", "exo", "exod"}},
+	Book{"Leviticus", "Lev", oldTestament, law, []string{"lev", "le", "lv"}},
+	Book{"Numbers", "Num", oldTestament, law, []string{"nu", "num", "numb", "nm"}},
+	Book{"Deuteronomy", "Deu", oldTestament, law, []string{"deu", "deut", "dt", "de"}},
 	Book{"Joshua", "Jos", oldTestament, history, []string{"jos", "josh"}},
-	Book{"Judges", "Jdg", oldTestament, history, []string{"jdg", "judg"}},
+	Book{"Judges", "Jdg", oldTestament, history, []string{"jdg", "judg", "jg"}},
 	Book{"Ruth", "Rut", oldTestament, history, []string{"ru", "rut"}},
-	Book{"1 Samuel", "1Sa", oldTestament, history, []string{"1sa", "1sam"}},
-	Book{"2 Samuel", "2Sa", oldTestament, history, []string{"2sa", "2sam"}},
-	Book{"1 Kings", "1Ki", oldTestament, history, []string{"1ki", "1kin", "1king"}},
-	Book{"2 Kings", "2Ki", oldTestament, history, []string{"2ki", "2kin", "2king"}},
-	Book{"1 Chronicles", "1Ch", oldTestament, history, []string{"1chro", "1chron"}},
-	Book{"2 Chronicles", "2Ch", oldTestament, history, []string{"2chro", "2chron"}},
+	Book{"1 Samuel", "1Sa", oldTestament, history, []string{"1sa", "1sam", "1sm"}},
+	Book{"2 Samuel", "2Sa", oldTestament, history, []string{"2sa", "2sam", "2sm"}},
+	Book{"1 Kings", "1Ki", oldTestament, history, []string{"1ki", "1kin", "1king", "1kgs"}},
+	Book{"2 Kings", "2Ki", oldTestament, history, []string{"2ki", "2kin", "2king", "2kgs"}},
+	Book{"1 Chronicles", "1Ch", oldTestament, history, []string{"1chro", "1chron", "1ch", "1chr"}},
+	Book{"2 Chronicles", "2Ch", oldTestament, history, []string{"2chro", "2chron", "2ch", "2chr"}},
 	Book{"Ezra", "Ezr", oldTestament, history, []string{"ez", "ezr"}},
 	Book{"Nehemiah", "Neh", oldTestament, history, []string{"ne", "neh"}},
 	Book{"Esther", "Est", oldTestament, history, []string{"es", "est", "esth"}},
 	Book{"Job", "Job", oldTestament, poetry, []string{}},
 	//	Book{"Psalm", "Psa", oldTestament, poetry, []string{"Ps", "Psalms"}},
-	Book{"Psalms", "Psa", oldTestament, poetry, []string{"ps", "psa", "psalm"}},
-	Book{"Proverbs", "Pro", oldTestament, poetry, []string{"pr", "pro", "prov"}},
-	Book{"Ecclesiastes", "Ecc", oldTestament, poetry, []string{"ecc", "ec", "eccles"}},
-	Book{"Song of Solomon", "Song", oldTestament, poetry, []string{"song", "song of songs"}},
+	Book{"Psalms", "Psa", oldTestament, poetry, []string{"ps", "psa", "psalm", "pss"}},
+	Book{"Proverbs", "Pro", oldTestament, poetry, []string{"pr", "pro", "prov", "prv"}},
+	Book{"Ecclesiastes", "Ecc", oldTestament, poetry, []string{"ecc", "ec", "eccles", "eccl", "qoh"}},
+	Book{"Song of Solomon", "Song", oldTestament, poetry, []string{"song", "song of songs", "sng", "sos"}},
 	//	Book{"Song of Songs", "Song", oldTestament, poetry, []string{}},
 	Book{"Isaiah", "Isa", oldTestament, prophesy, []string{"is", "isa"}},
 	Book{"Jeremiah", "Jer", oldTestament, prophesy, []string{"je", "jer", "jere"}},
 	Book{"Lamentations", "Lam", oldTestament, prophesy, []string{"la", "lam", "lamen"}},
-	Book{"Ezekiel", "Ezek", oldTestament, prophesy, []string{"ezek"}},
-	Book{"Daniel", "Dan", oldTestament, prophesy, []string{"dan"}},
-	Book{"Hosea", "Hos", oldTestament, prophesy, []string{"hos"}},
-	Book{"Joel", "Joel", oldTestament, prophesy, []string{"joe"}},
+	Book{"Ezekiel", "Ezek", oldTestament, prophesy, []string{"ezek", "eze", "ezk"}},
+	Book{"Daniel", "Dan", oldTestament, prophesy, []string{"dan", "dn", "da"}},
+	Book{"Hosea", "Hos", oldTestament, prophesy, []string{"hos", "ho"}},
+	Book{"Joel", "Joel", oldTestament, prophesy, []string{"joe", "jl"}},
 	Book{"Amos", "Amo", oldTestament, prophesy, []string{"am", "amo"}},
-	Book{"Obadiah", "Oba", oldTestament, prophesy, []string{"ob", "oba"}},
-	Book{"Jonah", "Jon", oldTestament, prophesy, []string{"jon"}},
-	Book{"Micah", "Mic", oldTestament, prophesy, []string{"mic"}},
+	Book{"Obadiah", "Oba", oldTestament, prophesy, []string{"ob", "oba", "obad"}},
+	Book{"Jonah", "Jon", oldTestament, prophesy, []string{"jon", "jnh"}},
+	Book{"Micah", "Mic", oldTestament, prophesy, []string{"mic", "mi"}},
 	Book{"Nahum", "Nah", oldTestament, prophesy, []string{"na", "nah"}},
-	Book{"Habakkuk", "Hab", oldTestament, prophesy, []string{"hab"}},
-	Book{"Zephaniah", "Zep", oldTestament, prophesy, []string{"zep", "zeph", "zef"}},
-	Book{"Haggai", "Hag", oldTestament, prophesy, []string{"hag", "hagg"}},
-	Book{"Zechariah", "Zec", oldTestament, prophesy, []string{"zec", "zech", "zek"}},
+	Book{"Habakkuk", "Hab", oldTestament, prophesy, []string{"hab", "hb"}},
+	Book{"Zephaniah", "Zep", oldTestament, prophesy, []string{"zep", "zeph", "zef", "zp"}},
+	Book{"Haggai", "Hag", oldTestament, prophesy, []string{"hag", "hagg", "hg"}},
+	Book{"Zechariah", "Zec", oldTestament, prophesy, []string{"zec", "zech", "zek", "zc"}},
 	Book{"Malachi", "Mal", oldTestament, prophesy, []string{"mal"}},
-	Book{"Matthew", "Mat", newTestament, gospel, []string{"mat", "matt"}},
-	Book{"Mark", "Mrk", newTestament, gospel, []string{"mrk", "mar"}},
-	Book{"Luke", "Luk", newTestament, gospel, []string{"lu", "luk"}},
-	Book{"John", "Jhn", newTestament, gospel, []string{"joh", "jhn"}},
+	Book{"Matthew", "Mat", newTestament, gospel, []string{"mat", "matt", "mt"}},
+	Book{"Mark", "Mrk", newTestament, gospel, []string{"mrk", "mar", "mk", "mr"}},
+	Book{"Luke", "Luk", newTestament, gospel, []string{"lu", "luk", "lk"}},
+	Book{"John", "Jhn", newTestament, gospel, []string{"joh", "jhn", "jn"}},
 	Book{"Acts", "Act", newTestament, history, []string{"ac", "act"}},
-	Book{"Romans", "Rom", newTestament, epistle, []string{"ro", "rom"}},
+	Book{"Romans", "Rom", newTestament, epistle, []string{"ro", "rom", "rm"}},
 	Book{"1 Corinthians", "1Co", newTestament, epistle, []string{"1co", "1cor"}},
 	Book{"2 Corinthians", "2Co", newTestament, epistle, []string{"2co", "2cor"}},
-	Book{"Galatians", "Gal", newTestament, epistle, []string{"gal"}},
-	Book{"Ephesians", "Eph", newTestament, epistle, []string{"eph"}},
-	Book{"Philippians", "Php", newTestament, epistle, []string{"php"}},
+	Book{"Galatians", "Gal", newTestament, epistle, []string{"gal", "ga"}},
+	Book{"Ephesians", "Eph", newTestament, epistle, []string{"eph", "ephes"}},
+	Book{"Philippians", "Php", newTestament, epistle, []string{"php", "phil", "pp"}},
 	Book{"Colossians", "Col", newTestament, epistle, []string{"col", "colo"}},
-	Book{"1 Thessalonians", "1Th", newTestament, epistle, []string{"1th", "1the", "1thess"}},
-	Book{"2 Thessalonians", "2Th", newTestament, epistle, []string{"2th", "2the", "2thess"}},
+	Book{"1 Thessalonians", "1Th", newTestament, epistle, []string{"1th", "1the", "1thess", "1thes"}},
+	Book{"2 Thessalonians", "2Th", newTestament, epistle, []string{"2th", "2the", "2thess", "2thes"}},
 	Book{"1 Timothy", "1Ti", newTestament, epistle, []string{"1ti", "1tim"}},
 	Book{"2 Timothy", "2Ti", newTestament, epistle, []string{"2ti", "2tim"}},
 	Book{"Titus", "Tit", newTestament, epistle, []string{"tit"}},
-	Book{"Philemon", "Phm", newTestament, epistle, []string{"phm", "philem"}},
+	Book{"Philemon", "Phm", newTestament, epistle, []string{"phm", "philem", "phlm"}},
 	Book{"Hebrews", "Heb", newTestament, epistle, []string{"heb"}},
-	Book{"James", "Jas", newTestament, epistle, []string{"jam", "jas", "jame"}},
-	Book{"1 Peter", "1Pe", newTestament, epistle, []string{"1pe", "1pet"}},
-	Book{"2 Peter", "2Pe", newTestament, epistle, []string{"2pe", "2pet"}},
-	Book{"1 John", "1Jn", newTestament, epistle, []string{"1jn", "1jo", "1joh"}},
-	Book{"2 John", "2Jn", newTestament, epistle, []string{"2jn", "2jo", "2joh"}},
-	Book{"3 John", "3Jn", newTestament, epistle, []string{"3jn", "3jo", "3joh"}},
+	Book{"James", "Jas", newTestament, epistle, []string{"jam", "jas", "jame", "jm"}},
+	Book{"1 Peter", "1Pe", newTestament, epistle, []string{"1pe", "1pet", "1pt"}},
+	Book{"2 Peter", "2Pe", newTestament, epistle, []string{"2pe", "2pet", "2pt"}},
+	Book{"1 John", "1Jn", newTestament, epistle, []string{"1jn", "1jo", "1joh", "1jhn"}},
+	Book{"2 John", "2Jn", newTestament, epistle, []string{"2jn", "2jo", "2joh", "2jhn"}},
+	Book{"3 John", "3Jn", newTestament, epistle, []string{"3jn", "3jo", "3joh", "3jhn"}},
 	Book{"Jude", "Jud", newTestament, epistle, []string{"jud"}},
-	Book{"Revelation", "Rev", newTestament, prophesy, []string{"rev", "revel"}},
+	Book{"Revelation", "Rev", newTestament, prophesy, []string{"rev", "revel", "re", "rv"}},
 }
 
 // DELETEME
@@ -219,14 +244,15 @@ func appendStringSliceMap(aMap map[string][]string, key string, value string) {
 
 }
 
-// displayErrorText writes an error message
+// displayErrorText writes an error message to stderr so that it does not
+// end up in the output of a piped subcommand.
 func displayErrorText(message string) {
-	color.Red.Println(message)
+	fmt.Fprintln(os.Stderr, theme.Error.Sprint(message))
 }
 
-// displayError writes an error message
+// displayError writes an error message to stderr
 func displayError(message string, err error) {
-	color.Red.Printf("%s: %v\n", message, err)
+	fmt.Fprintln(os.Stderr, theme.Error.Sprintf("%s: %v", message, err))
 }
 
 // debug only prints if the debugFlag is true
@@ -0,0 +1,315 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// A concordance lists every English word or phrase used to translate a
+// strongs number.  The translation map file gives the position of the words
+// in each ESV verse, so the verse text is looked up in batches and the words
+// at those positions are counted.
+//
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// verseBatchSize is the number of verses looked up in one request
+	verseBatchSize = 50
+
+	// maxConcordanceVerses limits the lookups for very common words
+	maxConcordanceVerses = 1000
+
+	// concordanceSamples is the number of references shown for a rendering
+	concordanceSamples = 5
+)
+
+var verseNumberRegex = regexp.MustCompile(`\[(\d+)\]`)
+
+// wordMapping is one entry of a translation map line.  i.e. "12+13=<2596>"
+// is words 12 and 13 of the verse translating strongs number 2596.
+type wordMapping struct {
+	Positions []int    // the positions of the English words, from 1
+	Strongs   []string // i.e. "g2596"
+}
+
+// rendering is one way a strongs number is translated
+type rendering struct {
+	English    string
+	Count      int
+	References []string
+}
+
+// concordance prints each English rendering of the strongs number with the
+// number of times it is used and some of the verses
+func concordance(strongsNum string) error {
+	strongsNum = strongsKey(strongsNum)
+	verses, err := versesUsingStrongs(strongsNum)
+	if err != nil {
+		displayError("Error reading "+translationMapFile, err)
+		return err
+	}
+	if len(verses) == 0 {
+		displayErrorText("No verses use " + strongsNum)
+		return errNotFound
+	}
+
+	if entry, err := lookupStrongs(strongsNum); err == nil {
+		theme.Heading.Printf("%s  %s", strings.ToUpper(entry.Key()), entry.Lemma)
+		theme.Muted.Printf("  %s\n", shortGloss(entry))
+	}
+	if textProvider.Name() != "ESV" {
+		theme.Muted.Printf("The strongs numbers are mapped to ESV words, so %s words may not line up.\n", textProvider.Name())
+	}
+	if len(verses) > maxConcordanceVerses {
+		theme.Muted.Printf("Using the first %d of %d verses.\n", maxConcordanceVerses, len(verses))
+		verses = verses[:maxConcordanceVerses]
+	}
+
+	words, err := fetchVerseWords(verses)
+	if err != nil {
+		return err
+	}
+
+	renderings := map[string]*rendering{}
+	for _, verseRef := range verses {
+		mapLine, err := translationMapLine(verseRef)
+		if err != nil {
+			debug("No translation map line for %s\n", verseRef)
+			continue
+		}
+		for _, mapping := range parseWordMappings(verseRef, mapLine) {
+			if !containsString(mapping.Strongs, strongsNum) {
+				continue
+			}
+			english := englishWords(words[verseRef], mapping.Positions)
+			if len(english) == 0 {
+				continue
+			}
+			r, ok := renderings[english]
+			if !ok {
+				r = &rendering{English: english}
+				renderings[english] = r
+			}
+			r.Count++
+			if len(r.References) == 0 || r.References[len(r.References)-1] != verseRef {
+				r.References = append(r.References, verseRef)
+			}
+		}
+	}
+	if len(renderings) == 0 {
+		displayErrorText("No English words found for " + strongsNum)
+		return errNotFound
+	}
+
+	printRenderings(renderings, len(verses))
+	return nil
+}
+
+// printRenderings prints the renderings, most used first
+func printRenderings(renderings map[string]*rendering, numVerses int) {
+	var sorted []*rendering
+	width := 0
+	for _, r := range renderings {
+		sorted = append(sorted, r)
+		if len(r.English) > width {
+			width = len(r.English)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].English < sorted[j].English
+	})
+
+	fmt.Printf("%d renderings in %d verses\n", len(sorted), numVerses)
+	format := "  %-" + strconv.Itoa(width) + "s %5d  "
+	for _, r := range sorted {
+		fmt.Printf(format, r.English, r.Count)
+		samples := r.References
+		if len(samples) > concordanceSamples {
+			samples = samples[:concordanceSamples]
+		}
+		theme.Muted.Print(strings.Join(samples, ", "))
+		if len(r.References) > len(samples) {
+			theme.Muted.Printf(" (+%d)", len(r.References)-len(samples))
+		}
+		fmt.Println()
+	}
+}
+
+// parseWordMappings parses the mappings of a translation map line.
+// i.e. "01=<3972> 12+13=<2596> 15=<1161>+<2532>"
+func parseWordMappings(verseRef, mapLine string) []wordMapping {
+	prefix := "h"
+	if ref, err := ParseReference(verseRef); err == nil && ref.Book.Testament == newTestament {
+		prefix = "g"
+	}
+
+	var mappings []wordMapping
+	for _, field := range strings.Fields(mapLine) {
+		eqIx := strings.Index(field, "=")
+		if eqIx < 0 {
+			continue
+		}
+		var mapping wordMapping
+		for _, position := range strings.Split(field[:eqIx], "+") {
+			if n, err := strconv.Atoi(position); err == nil {
+				mapping.Positions = append(mapping.Positions, n)
+			}
+		}
+		for _, number := range numberRegex.FindAllString(field[eqIx+1:], -1) {
+			mapping.Strongs = append(mapping.Strongs, strongsKey(prefix+number))
+		}
+		if len(mapping.Positions) > 0 && len(mapping.Strongs) > 0 {
+			mappings = append(mappings, mapping)
+		}
+	}
+	return mappings
+}
+
+// fetchVerseWords looks up the verses in batches and returns the words of
+// each, keyed by the reference as given.  The words are split by verseWords
+// to match the word positions of the translation map file.
+func fetchVerseWords(verseRefs []string) (map[string][]string, error) {
+	texts, err := fetchVerseTexts(verseRefs)
+	if err != nil {
+		return nil, err
+	}
+	words := map[string][]string{}
+	for verseRef, text := range texts {
+		words[verseRef] = verseWords(text)
+	}
+	return words, nil
+}
+
+// fetchVerseTexts looks up the verses in batches and returns the text of
+// each without its verse number, keyed by the reference as given.  Verses
+// the text does not have are not in the map.
+func fetchVerseTexts(verseRefs []string) (map[string]string, error) {
+	verseTexts := map[string]string{}
+	copyright := "(" + textProvider.Name() + ")"
+	for start := 0; start < len(verseRefs); start += verseBatchSize {
+		end := start + verseBatchSize
+		if end > len(verseRefs) {
+			end = len(verseRefs)
+		}
+		batch := verseRefs[start:end]
+
+		passage, err := lookupVerse(strings.Join(batch, "; "), 0,
+			false, /*includeHeadings*/
+			false, /*includeFootnotes*/
+			false, /*indentPoetry*/
+			true /*includeVerseNumbers*/)
+		if err != nil {
+			displayError("Error looking up verses", err)
+			return nil, err
+		}
+
+		// Each verse starts with its number in square brackets.  Adjacent
+		// verses may be in one passage, so match the numbers to the batch in
+		// order.  Verses missing from the text are left out.
+		next := 0
+		for _, p := range passage.Passages {
+			lines := newlineRegex.Split(p, 2)
+			if len(lines) < 2 {
+				continue
+			}
+			text := strings.Replace(lines[1], copyright, "", 1)
+			locations := verseNumberRegex.FindAllStringSubmatchIndex(text, -1)
+			for i, loc := range locations {
+				verseEnd := len(text)
+				if i+1 < len(locations) {
+					verseEnd = locations[i+1][0]
+				}
+				number := leadingInt(text[loc[2]:loc[3]])
+				for next < len(batch) && verseNumber(batch[next]) != number {
+					next++
+				}
+				if next == len(batch) {
+					break
+				}
+				verseTexts[batch[next]] = strings.TrimSpace(text[loc[1]:verseEnd])
+				next++
+			}
+		}
+	}
+	return verseTexts, nil
+}
+
+// verseNumber returns the verse number of a reference.  i.e. 28 for "Rom 8:28"
+func verseNumber(verseRef string) int {
+	return leadingInt(verseRef[strings.LastIndex(verseRef, ":")+1:])
+}
+
+// englishWords returns the words at the positions (from 1) without their
+// punctuation.  Words that are not next to each other are joined with "...".
+func englishWords(words []string, positions []int) string {
+	var sb strings.Builder
+	previous := 0
+	for _, position := range positions {
+		if position < 1 || position > len(words) {
+			continue
+		}
+		word := cleanWord(words[position-1])
+		if len(word) == 0 {
+			continue
+		}
+		if sb.Len() > 0 {
+			if position == previous+1 {
+				sb.WriteString(" ")
+			} else {
+				sb.WriteString(" ... ")
+			}
+		}
+		sb.WriteString(word)
+		previous = position
+	}
+	return sb.String()
+}
+
+// cleanWord removes the punctuation around a word and makes it lower case,
+// except for words in capitals like LORD
+func cleanWord(word string) string {
+	word = strings.TrimFunc(word, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if word != strings.ToUpper(word) || len(word) == 1 {
+		word = strings.ToLower(word)
+	}
+	return word
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// User settings come from, in increasing order of precedence:
+//   1. defaults
+//   2. the config file, ~/.biblestudy-data/config.toml
+//   3. environment variables
+//   4. command-line flags
+//
+// Example config.toml:
+//
+//   declarations_file = "/keybase/private/joncrlsn/declarations"
+//   declarations_pdf = "/tmp/declarations.pdf"
+//   esv_api_token = "0123456789abcdef"
+//   line_length = 100
+//   color_theme = "light"
+//   text_provider = "local"
+//   votd_file = "/home/jon/votd.txt"
+//
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+const (
+	configFileName          = "config.toml"
+	defaultDeclarationsName = "declarations"
+	defaultApiToken         = "9f29b9475bd3e4b05765c06741cf4c094eef8a8a"
+)
+
+// config holds the settings in effect, see loadConfig
+var config Config
+
+// Config holds the user settings
+type Config struct {
+	DeclarationsFile string `toml:"declarations_file"`
+	DeclarationsPdf  string `toml:"declarations_pdf"`
+	ESVApiToken      string `toml:"esv_api_token"`
+	LineLength       int    `toml:"line_length"`
+	ColorTheme       string `toml:"color_theme"`
+	TextProvider     string `toml:"text_provider"` // esv or local, empty for esv
+	VotdFile         string `toml:"votd_file"`     // verse of the day references, one per line
+
+	// fileName is the config file that was read, if any
+	fileName string
+}
+
+// configSetting ties a setting to its environment variable and flag
+type configSetting struct {
+	envName  string
+	flagName string
+	usage    string
+	value    func(c *Config) *string
+}
+
+var configSettings = []configSetting{
+	{"BIBLESTUDY_DECLARATIONS", "declarations", "declarations file", func(c *Config) *string { return &c.DeclarationsFile }},
+	{"BIBLESTUDY_PDF", "pdf", "PDF file to print declarations to", func(c *Config) *string { return &c.DeclarationsPdf }},
+	{"ESV_API_TOKEN", "token", "ESV API token", func(c *Config) *string { return &c.ESVApiToken }},
+	{"BIBLESTUDY_THEME", "theme", "color theme: default, light or none", func(c *Config) *string { return &c.ColorTheme }},
+	{"BIBLESTUDY_PROVIDER", "provider", "Bible text provider: esv or local", func(c *Config) *string { return &c.TextProvider }},
+	{"BIBLESTUDY_VOTD", "votd", "verse of the day file", func(c *Config) *string { return &c.VotdFile }},
+}
+
+// defaultConfig returns the settings used when nothing else is given
+func defaultConfig() Config {
+	return Config{
+		DeclarationsFile: filepath.Join(dataDirPath, defaultDeclarationsName),
+		DeclarationsPdf:  "declarations.pdf",
+		ESVApiToken:      defaultApiToken,
+		LineLength:       80,
+		ColorTheme:       "default",
+		VotdFile:         filepath.Join(dataDirPath, defaultVotdName),
+	}
+}
+
+// loadConfig parses the command-line flags and builds the config from the
+// defaults, config file, environment and flags.  The remaining command-line
+// arguments (the subcommand) are returned.
+func loadConfig(arguments []string) ([]string, error) {
+	flags := flag.NewFlagSet("biblestudy", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: biblestudy [flags] [subcommand [arguments]]")
+		fmt.Fprintln(os.Stderr, "Flags:")
+		flags.PrintDefaults()
+		fmt.Fprintln(os.Stderr)
+		printHelpSubcommands()
+	}
+
+	configFile := flags.String("config", "", "config file (env BIBLESTUDY_CONFIG)")
+	lineLength := flags.Int("line-length", 0, "line length of verse text (env BIBLESTUDY_LINE_LENGTH)")
+	var flagValues Config
+	for _, setting := range configSettings {
+		flags.StringVar(setting.value(&flagValues), setting.flagName, "",
+			fmt.Sprintf("%s (env %s)", setting.usage, setting.envName))
+	}
+	if err := flags.Parse(arguments); err == flag.ErrHelp {
+		return nil, err
+	} else if err != nil {
+		return nil, errors.Wrap(errUsage, err.Error())
+	}
+
+	config = defaultConfig()
+
+	// The config file
+	config.fileName = *configFile
+	if len(config.fileName) == 0 {
+		config.fileName = os.Getenv("BIBLESTUDY_CONFIG")
+	}
+	if len(config.fileName) == 0 {
+		config.fileName = filepath.Join(dataDirPath, configFileName)
+		if exists, _ := Exists(config.fileName); !exists {
+			config.fileName = ""
+		}
+	}
+	if len(config.fileName) > 0 {
+		if _, err := toml.DecodeFile(config.fileName, &config); err != nil {
+			return nil, errors.Wrap(err, "Error reading config file "+config.fileName)
+		}
+	}
+
+	// The environment
+	for _, setting := range configSettings {
+		if value := os.Getenv(setting.envName); len(value) > 0 {
+			*setting.value(&config) = value
+		}
+	}
+	if value := os.Getenv("BIBLESTUDY_LINE_LENGTH"); len(value) > 0 {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Wrap(errUsage, "BIBLESTUDY_LINE_LENGTH must be a number")
+		}
+		config.LineLength = n
+	}
+
+	// The flags
+	for _, setting := range configSettings {
+		if value := *setting.value(&flagValues); len(value) > 0 {
+			*setting.value(&config) = value
+		}
+	}
+	if *lineLength > 0 {
+		config.LineLength = *lineLength
+	}
+
+	if err := setTheme(config.ColorTheme); err != nil {
+		return nil, err
+	}
+
+	return flags.Args(), nil
+}
+
+// printConfig shows the settings in effect
+func printConfig() {
+	if len(config.fileName) > 0 {
+		fmt.Printf("Config file:       %s\n", config.fileName)
+	} else {
+		fmt.Printf("Config file:       none (create %s)\n", filepath.Join(dataDirPath, configFileName))
+	}
+	fmt.Printf("Declarations file: %s\n", config.DeclarationsFile)
+	fmt.Printf("Declarations PDF:  %s\n", config.DeclarationsPdf)
+	fmt.Printf("Line length:       %d\n", config.LineLength)
+	fmt.Printf("Color theme:       %s\n", config.ColorTheme)
+	fmt.Printf("Text provider:     %s\n", textProvider.Name())
+	if exists, _ := Exists(config.VotdFile); exists {
+		fmt.Printf("Verse of the day:  %s\n", config.VotdFile)
+	} else {
+		fmt.Printf("Verse of the day:  built-in list (create %s)\n", config.VotdFile)
+	}
+}
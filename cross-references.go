@@ -0,0 +1,339 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Lists the cross references of a passage from the OpenBible.info dataset,
+// ranked by the votes of its users.  The dataset is downloaded into the data
+// directory the first time it is needed.  Each line has the verse, the verse
+// or range it refers to and the votes, separated by tabs:
+//
+//	Rom.8.28	Gen.50.20	165
+//	Rom.8.28	Eph.1.11-Eph.1.12	120
+//
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	crossRefsFileName = "cross-references.txt"
+	crossRefsURL      = "https://a.openbible.info/data/cross-references.zip"
+
+	// defaultCrossRefs is the number of cross references listed when no
+	// number is given
+	defaultCrossRefs = 10
+)
+
+// crossRef is a verse or range referred to by a passage and its votes
+type crossRef struct {
+	Ref   Reference
+	Votes int
+}
+
+// crossReferences lists the cross references of the passage with the most
+// votes.  The options may include how many to list (or all), "show" to
+// display their text in one lookup, and "in" followed by the books,
+// categories or testaments to keep.  i.e. "20 in epistles" or "show in nt"
+func crossReferences(passageRef, options string) error {
+	refs, err := ParseReferences(passageRef)
+	if err != nil {
+		displayErrorText(err.Error())
+		return err
+	}
+	limit, show, filterWords, err := parseCrossRefOptions(options)
+	if err != nil {
+		return err
+	}
+
+	xrefs, err := loadCrossRefs(refs)
+	if err != nil {
+		displayError("Error reading cross references", err)
+		return err
+	}
+	if len(filterWords) > 0 {
+		bookNames := map[string]bool{}
+		for _, name := range *findBooksMatchingWords(filterWords) {
+			bookNames[name] = true
+		}
+		var kept []crossRef
+		for _, xref := range xrefs {
+			if bookNames[xref.Ref.Book.TranslationName] {
+				kept = append(kept, xref)
+			}
+		}
+		xrefs = kept
+	}
+	if len(xrefs) == 0 {
+		displayErrorText("No cross references found for " + FormatReferences(refs))
+		return errNotFound
+	}
+
+	sortCrossRefs(xrefs)
+	total := len(xrefs)
+	if limit > 0 && limit < total {
+		xrefs = xrefs[:limit]
+	}
+
+	if show {
+		var shown []Reference
+		for _, xref := range xrefs {
+			shown = append(shown, xref.Ref)
+		}
+		_, err := displayPassage(FormatReferences(shown),
+			false, /*includeHeadings*/
+			false, /*includeFootnotes*/
+			true,  /*indentPoetry*/
+			true /*includeVerseNumbers*/)
+		return err
+	}
+
+	fmt.Printf("Cross references for %s (%d of %d by votes)\n", FormatReferences(refs), len(xrefs), total)
+	width := 0
+	for _, xref := range xrefs {
+		if len(xref.Ref.String()) > width {
+			width = len(xref.Ref.String())
+		}
+	}
+	err = runPager(len(xrefs), func(start, end int) error {
+		for i, xref := range xrefs[start:end] {
+			fmt.Printf("  %3d) %-*s", start+i+1, width, xref.Ref.String())
+			theme.Muted.Printf("  %d votes\n", xref.Votes)
+		}
+		return nil
+	})
+	if interactive {
+		theme.Hint.Println("Enter x show to read them (x 20, x all and x in epistles also work)")
+	}
+	return err
+}
+
+// splitCrossRefArgs splits the arguments of the xrefs subcommand into the
+// longest leading reference and the options after it.  i.e. "Rom 8:28 20 in
+// nt" is "Rom 8:28" and "20 in nt"
+func splitCrossRefArgs(text string) (passageRef, options string) {
+	words := strings.Fields(text)
+	for i := len(words); i > 0; i-- {
+		if _, err := ParseReferences(strings.Join(words[:i], " ")); err == nil {
+			return strings.Join(words[:i], " "), strings.Join(words[i:], " ")
+		}
+	}
+	return text, ""
+}
+
+// parseCrossRefOptions reads the options of crossReferences and displays any
+// it does not understand
+func parseCrossRefOptions(options string) (limit int, show bool, filterWords []string, err error) {
+	limit = defaultCrossRefs
+	afterIn := false
+	for _, word := range strings.Fields(strings.ToLower(options)) {
+		n, convErr := strconv.Atoi(word)
+		switch {
+		case word == "show":
+			show = true
+		case word == "all":
+			limit = 0
+		case word == "in":
+			afterIn = true
+		case convErr == nil && n > 0:
+			limit = n
+		case afterIn:
+			if _, ok := filters[word]; !ok {
+				displayErrorText("Unknown book, category or testament: " + word)
+				return 0, false, nil, errUsage
+			}
+			filterWords = append(filterWords, word)
+		default:
+			displayErrorText("Unknown cross reference option: " + word)
+			return 0, false, nil, errUsage
+		}
+	}
+	if afterIn && len(filterWords) == 0 {
+		displayErrorText("Expected books, categories or testaments after in")
+		return 0, false, nil, errUsage
+	}
+	return limit, show, filterWords, nil
+}
+
+// sortCrossRefs puts the most votes first, then the order of the books
+func sortCrossRefs(xrefs []crossRef) {
+	sort.SliceStable(xrefs, func(i, j int) bool {
+		a, b := xrefs[i], xrefs[j]
+		switch {
+		case a.Votes != b.Votes:
+			return a.Votes > b.Votes
+		case a.Ref.Book.Number() != b.Ref.Book.Number():
+			return a.Ref.Book.Number() < b.Ref.Book.Number()
+		case a.Ref.StartChapter != b.Ref.StartChapter:
+			return a.Ref.StartChapter < b.Ref.StartChapter
+		default:
+			return a.Ref.StartVerse < b.Ref.StartVerse
+		}
+	})
+}
+
+// loadCrossRefs returns the cross references of every verse in the
+// references.  The votes of a reference made by more than one verse are
+// added together, and references inside the passage itself are left out.
+func loadCrossRefs(refs []Reference) ([]crossRef, error) {
+	fileName := filepath.Join(dataDirPath, crossRefsFileName)
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		if err := downloadCrossRefs(fileName); err != nil {
+			return nil, errors.Wrap(err, "Error downloading "+crossRefsURL)
+		}
+	}
+
+	wanted := map[string]bool{}
+	for _, ref := range refs {
+		for _, verse := range ref.Verses() {
+			wanted[osisRef(verse)] = true
+		}
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	votes := map[string]*crossRef{}
+	var order []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 || !wanted[fields[0]] {
+			continue
+		}
+		target, ok := parseOSISRange(fields[1])
+		if !ok || inReferences(refs, target) {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(fields[2]))
+		if err != nil {
+			continue
+		}
+		key := target.String()
+		if xref, ok := votes[key]; ok {
+			xref.Votes += n
+			continue
+		}
+		votes[key] = &crossRef{Ref: target, Votes: n}
+		order = append(order, key)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Negative votes mean the users found the reference unhelpful
+	var xrefs []crossRef
+	for _, key := range order {
+		if votes[key].Votes >= 0 {
+			xrefs = append(xrefs, *votes[key])
+		}
+	}
+	return xrefs, nil
+}
+
+// downloadCrossRefs downloads the OpenBible.info zip file and extracts the
+// cross references from it
+func downloadCrossRefs(fileName string) error {
+	fmt.Fprintln(os.Stderr, "Downloading cross references from openbible.info")
+	zipFile := fileName + ".zip"
+	if err := DownloadFile(zipFile, crossRefsURL); err != nil {
+		return err
+	}
+	defer os.Remove(zipFile)
+
+	archive, err := zip.OpenReader(zipFile)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if !strings.HasSuffix(f.Name, ".txt") {
+			continue
+		}
+		in, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(fileName + ".tmp")
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		out.Close()
+		return os.Rename(fileName+".tmp", fileName)
+	}
+	return errors.New("No cross references file in " + crossRefsURL)
+}
+
+// osisRef returns the OSIS name of a single verse.  i.e. "Rom.8.28"
+func osisRef(verse Reference) string {
+	return fmt.Sprintf("%s.%d.%d", osisBookIDs[verse.Book.Number()-1], verse.StartChapter, verse.StartVerse)
+}
+
+// parseOSISRange converts an OSIS verse or range like "Eph.1.11-Eph.1.12"
+// into a Reference.  A range that ends in another book ends at its first
+// verse.
+func parseOSISRange(osisRange string) (Reference, bool) {
+	start, end := osisRange, osisRange
+	if ix := strings.Index(osisRange, "-"); ix >= 0 {
+		start, end = osisRange[:ix], osisRange[ix+1:]
+	}
+	bookIx, chapter, verse := parseOSISRef(start, osisBookIDs)
+	if bookIx < 0 || chapter < 1 || verse < 1 {
+		return Reference{}, false
+	}
+	ref := Reference{books[bookIx], chapter, verse, chapter, verse}
+	endIx, endChapter, endVerse := parseOSISRef(end, osisBookIDs)
+	if endIx == bookIx && (endChapter > chapter || (endChapter == chapter && endVerse > verse)) {
+		ref.EndChapter, ref.EndVerse = endChapter, endVerse
+	}
+	return ref, true
+}
+
+// inReferences returns true if the reference starts inside one of the refs
+func inReferences(refs []Reference, ref Reference) bool {
+	for _, r := range refs {
+		if r.Book.TranslationName == ref.Book.TranslationName && r.Includes(ref.StartChapter, ref.StartVerse) {
+			return true
+		}
+	}
+	return false
+}
@@ -24,22 +24,34 @@ package main
 //
 // What is a declaration?
 // It is a bible verse that you have reworded to make it more personal, then
-// added to your declarations file, one declaration per line.
+// added to your declarations file.
 //
 // i.e. I am born of God, the evil one cannot touch me.  - 1 John 5:18
+//
+// The declarations file has one JSON object per line:
+//
+//	{"id":1,"text":"I am born of God, the evil one cannot touch me.","reference":"1 John 5:18",
+//	 "verseText":"We know that everyone who has been born of God does not keep on sinning...",
+//	 "tags":["identity"],"created":"2020-05-01T08:00:00Z","lastReviewed":"2020-06-01T08:00:00Z"}
+//
+// A plain text file with one declaration per line is migrated automatically.
+//
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/rand"
+	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	wordwrap "github.com/mitchellh/go-wordwrap"
+	"github.com/pkg/errors"
 )
 
 const (
-	declarationLineWidth    = 37
-	declarationsPdfFilename = "declarations.pdf"
-	declarationsFilename    = "/keybase/private/joncrlsn/declarations"
+	declarationLineWidth = 37
 )
 
 // referenceRegex expects the declaration to end with a period followed
@@ -47,25 +59,217 @@ const (
 // i.e.  I stand in grace.  - Rom 5:2
 var referenceRegex = regexp.MustCompile(`\.\s+-`)
 
-// displayRandomDeclaration assumes a file with a declaration per line.
-func displayRandomDeclaration() {
+// Declaration is a personalized verse
+type Declaration struct {
+	ID           int        `json:"id"`
+	Text         string     `json:"text"`      // i.e. "I stand in grace."
+	Reference    string     `json:"reference"` // i.e. "Romans 5:2"
+	VerseText    string     `json:"verseText,omitempty"`
+	Tags         []string   `json:"tags,omitempty"`
+	Created      time.Time  `json:"created"`
+	LastReviewed *time.Time `json:"lastReviewed,omitempty"`
+}
+
+// String returns the declaration on one line.  i.e. "I stand in grace.  - Romans 5:2"
+func (d Declaration) String() string {
+	return d.Text + "  - " + d.Reference
+}
+
+// HasTag returns true if the declaration has the tag, ignoring case
+func (d Declaration) HasTag(tag string) bool {
+	for _, t := range d.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
 
-	line, err := grepRandom(declarationsFilename)
+// displayRandomDeclaration displays a random declaration, only choosing from
+// those with the tag if one is given.
+func displayRandomDeclaration(tag string) error {
+	declarations, err := loadDeclarations()
 	if err != nil {
-		displayError("Error reading declarations file", err)
+		return err
+	}
+	if len(tag) > 0 {
+		declarations = filterDeclarations(declarations, tag)
+	}
+	if len(declarations) == 0 {
+		displayErrorText("No declarations found")
+		return errNotFound
 	}
 
-	// Convert this:
-	// ... cannot not touch me.   - 1 John 5:18
-	// into this on two lines:
+	printDeclaration(declarations[rand.Intn(len(declarations))])
+	return nil
+}
+
+// printDeclaration displays the declaration with a border, wrapped at the
+// declaration line width
+func printDeclaration(d Declaration) {
+	// Put the reference on its own line:
 	// ... cannot not touch me.
 	//     - 1 John 5:18
-	line = referenceRegex.ReplaceAllString(line, ".\n    -")
-
-	// Now add a border and wrap the lines at the given length
+	wrapped := wordwrap.WrapString(d.Text, declarationLineWidth)
 	border := strings.Repeat("=", declarationLineWidth)
-	wrapped := wordwrap.WrapString(line, declarationLineWidth)
 	fmt.Println(border)
 	fmt.Println(wrapped)
+	fmt.Println("    - " + d.Reference)
 	fmt.Println(border)
 }
+
+// filterDeclarations returns only the declarations with the tag
+func filterDeclarations(declarations []Declaration, tag string) []Declaration {
+	var filtered []Declaration
+	for _, d := range declarations {
+		if d.HasTag(tag) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// loadDeclarations reads the declarations file, migrating it first if it is
+// plain text.  A missing file has no declarations.
+func loadDeclarations() ([]Declaration, error) {
+	if exists, _ := Exists(config.DeclarationsFile); !exists {
+		return nil, nil
+	}
+	lines, err := ReadLines(config.DeclarationsFile)
+	if err != nil {
+		displayError("Error reading declarations file", err)
+		return nil, err
+	}
+
+	var declarations []Declaration
+	plainText := false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !strings.HasPrefix(line, "{") {
+			plainText = true
+			continue
+		}
+		d := Declaration{}
+		if err := json.Unmarshal([]byte(line), &d); err != nil {
+			err = errors.Wrapf(err, "Error on line %d of %s", i+1, config.DeclarationsFile)
+			displayError("Error reading declarations file", err)
+			return nil, err
+		}
+		declarations = append(declarations, d)
+	}
+
+	if plainText {
+		return migrateDeclarations(lines, declarations)
+	}
+	return declarations, nil
+}
+
+// migrateDeclarations converts the plain text lines of the declarations
+// file, with one declaration per line, to JSON lines.  The declarations that
+// are already JSON are kept as they are, in the same order as the lines.  The
+// original file is kept with a .bak extension, without replacing an earlier
+// backup.
+func migrateDeclarations(lines []string, declarations []Declaration) ([]Declaration, error) {
+	backupFile := config.DeclarationsFile + ".bak"
+	for n := 1; ; n++ {
+		if exists, _ := Exists(backupFile); !exists {
+			break
+		}
+		backupFile = fmt.Sprintf("%s.bak%d", config.DeclarationsFile, n)
+	}
+	if err := WriteLinesAtomic(backupFile, lines); err != nil {
+		displayError("Error backing up declarations file", err)
+		return nil, err
+	}
+
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		return nil, err
+	}
+	nextID := schedule.nextDeclarationID(declarations)
+
+	// The JSON declarations were parsed in the order of their lines
+	var merged []Declaration
+	migrated := 0
+	now := time.Now()
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			merged = append(merged, declarations[0])
+			declarations = declarations[1:]
+			continue
+		}
+		text, ref := splitDeclaration(line)
+		d := Declaration{
+			ID:        nextID,
+			Text:      text,
+			Reference: canonicalReference(ref),
+			Created:   now,
+		}
+		if len(d.Reference) > 0 {
+			d.VerseText = lookupDeclarationVerse(d.Reference)
+		}
+		merged = append(merged, d)
+		nextID++
+		migrated++
+	}
+
+	if err := saveDeclarations(merged); err != nil {
+		return nil, err
+	}
+	schedule.LastDeclarationID = nextID - 1
+	if err := saveReviewSchedule(schedule); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Migrated %d declarations in %s to the structured format.  The original is in %s\n",
+		migrated, config.DeclarationsFile, backupFile)
+	return merged, nil
+}
+
+// saveDeclarations replaces the declarations file atomically
+func saveDeclarations(declarations []Declaration) error {
+	lines := make([]string, 0, len(declarations))
+	for _, d := range declarations {
+		line, err := json.Marshal(d)
+		if err != nil {
+			displayError("Error saving declaration", err)
+			return err
+		}
+		lines = append(lines, string(line))
+	}
+
+	if err := WriteLinesAtomic(config.DeclarationsFile, lines); err != nil {
+		displayError("Error writing declarations file", err)
+		return err
+	}
+	return nil
+}
+
+// splitDeclaration splits a plain text declaration into its text and
+// reference using the last match of referenceRegex.  The reference is empty
+// if there is none.
+func splitDeclaration(line string) (text string, reference string) {
+	locations := referenceRegex.FindAllStringIndex(line, -1)
+	if locations == nil {
+		return line, ""
+	}
+	last := locations[len(locations)-1]
+	return line[:last[0]+1], strings.TrimSpace(line[last[1]:])
+}
+
+// canonicalReference returns the reference with the full book name.
+// i.e. "rom 5:2" becomes "Romans 5:2".  It is returned unchanged if it
+// cannot be parsed.
+func canonicalReference(ref string) string {
+	refs, err := ParseReferences(ref)
+	if err != nil {
+		return ref
+	}
+	return FormatReferences(refs)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDeclarationsMixedFile(t *testing.T) {
+	dir := useDataDir(t)
+	savedFile := config.DeclarationsFile
+	config.DeclarationsFile = filepath.Join(dir, "declarations")
+	defer func() { config.DeclarationsFile = savedFile }()
+
+	savedProvider := textProvider
+	textFile := filepath.Join(dir, "bible-text.txt")
+	textProvider = newLocalProvider(textFile)
+	defer func() { textProvider = savedProvider }()
+	if err := WriteLinesAtomic(textFile, []string{"# KJV", "1Jn 4:19\tWe love him, because he first loved us."}); err != nil {
+		t.Fatal(err)
+	}
+
+	original := []string{
+		"I am loved.  - 1 John 4:19",
+		`{"id":4,"text":"I stand in grace.","reference":"Romans 5:2","tags":["identity"],"created":"2020-01-02T00:00:00Z"}`,
+		"I am chosen.",
+	}
+	if err := WriteLinesAtomic(config.DeclarationsFile, original); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteLinesAtomic(config.DeclarationsFile+".bak", []string{"older backup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	declarations, err := loadDeclarations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(declarations) != 3 {
+		t.Fatalf("loaded %d declarations, want 3", len(declarations))
+	}
+
+	// The declarations keep the order of the lines
+	migrated := declarations[0]
+	if migrated.ID != 5 || migrated.Text != "I am loved." || migrated.Reference != "1 John 4:19" ||
+		migrated.VerseText != "We love him, because he first loved us." {
+		t.Errorf("plain declaration migrated as %+v", migrated)
+	}
+	kept := declarations[1]
+	if kept.ID != 4 || kept.Text != "I stand in grace." || kept.Reference != "Romans 5:2" ||
+		len(kept.Tags) != 1 || kept.Tags[0] != "identity" {
+		t.Errorf("JSON declaration changed: %+v", kept)
+	}
+	if noRef := declarations[2]; noRef.ID != 6 || noRef.Text != "I am chosen." || len(noRef.Reference) != 0 {
+		t.Errorf("declaration without a reference migrated as %+v", noRef)
+	}
+
+	// The earlier backup is kept and the mixed file is backed up separately
+	if lines, _ := ReadLines(config.DeclarationsFile + ".bak"); len(lines) != 1 || lines[0] != "older backup" {
+		t.Errorf("earlier backup was replaced: %v", lines)
+	}
+	lines, err := ReadLines(config.DeclarationsFile + ".bak1")
+	if err != nil || strings.Join(lines, "\n") != strings.Join(original, "\n") {
+		t.Errorf("backup of the mixed file is %v, %v", lines, err)
+	}
+
+	// Loading again finds only JSON and does not migrate
+	again, err := loadDeclarations()
+	if err != nil || len(again) != 3 {
+		t.Errorf("reloaded %d declarations, %v", len(again), err)
+	}
+	if exists, _ := Exists(config.DeclarationsFile + ".bak2"); exists {
+		t.Error("the JSON file was migrated again")
+	}
+}
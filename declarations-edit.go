@@ -0,0 +1,344 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Add, list, edit, tag, delete and validate the declarations in the
+// declarations file.  Every change rewrites the whole file atomically so it
+// is never left half written.
+//
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// declarationCommandPrefix matches a declaration command at the prompt
+	declarationCommandPrefix = regexp.MustCompile(`(?i)^(d|da|dl|de|dt|dd|dv|declaration)(?:\s+(.*))?$`)
+
+	declarationCommandRegex = regexp.MustCompile(`(?i)^(add|list|edit|tag|delete|validate)\b\s*(.*)$`)
+	declarationIndexRegex   = regexp.MustCompile(`^(\d+)\s*(.*)$`)
+	hashTagRegex            = regexp.MustCompile(`(?:^|\s)#(\w[\w-]*)`)
+
+	// declarationAliases are the short REPL commands for each action
+	declarationAliases = map[string]string{
+		"da": "add",
+		"dl": "list",
+		"de": "edit",
+		"dt": "tag",
+		"dd": "delete",
+		"dv": "validate",
+	}
+)
+
+// runDeclarationCommand runs one of these actions on the declarations file:
+//
+//	add I stand in grace. #identity  - adds a declaration for the current verse
+//	list [tag]                       - lists the declarations with their numbers
+//	edit 3 I stand in grace.         - replaces the text of declaration 3
+//	tag 3 identity fear              - replaces the tags of declaration 3
+//	delete 3                         - deletes declaration 3
+//	validate                         - lists the declarations without a text or reference
+//
+// With no action a random declaration is displayed, or with a single word a
+// random declaration with that tag.
+func runDeclarationCommand(args string) error {
+	args = strings.TrimSpace(args)
+	if len(args) == 0 {
+		return displayRandomDeclaration("")
+	}
+
+	matches := declarationCommandRegex.FindStringSubmatch(args)
+	if matches == nil {
+		if words := strings.Fields(args); len(words) == 1 {
+			return displayRandomDeclaration(strings.TrimPrefix(words[0], "#"))
+		}
+		displayErrorText("Unknown declaration command: " + args)
+		return errUsage
+	}
+	action, args := strings.ToLower(matches[1]), matches[2]
+
+	switch action {
+	case "add":
+		return addDeclaration(args)
+	case "list":
+		return listDeclarations(strings.TrimPrefix(strings.TrimSpace(args), "#"))
+	case "validate":
+		return validateDeclarations()
+	}
+
+	// edit, tag and delete need the number of a declaration
+	matches = declarationIndexRegex.FindStringSubmatch(args)
+	if matches == nil {
+		displayErrorText("Expected the number of a declaration.  Use 'dl' to list them.")
+		return errUsage
+	}
+	n, _ := strconv.Atoi(matches[1])
+	switch action {
+	case "edit":
+		return editDeclaration(n, matches[2])
+	case "tag":
+		return tagDeclaration(n, strings.Fields(strings.ReplaceAll(matches[2], "#", "")))
+	}
+	return deleteDeclaration(n)
+}
+
+// addDeclaration adds a declaration for the current verse, unless the text
+// ends with its own reference.  Words starting with # become tags.
+func addDeclaration(args string) error {
+	text, tags := splitHashTags(args)
+	text, reference := splitDeclaration(text)
+	if len(reference) == 0 {
+		reference = previousPassageRef
+	}
+	d := Declaration{
+		Text:      text,
+		Reference: canonicalReference(reference),
+		Tags:      tags,
+		Created:   time.Now(),
+	}
+	if err := validateDeclaration(d); err != nil {
+		displayError("Unable to add declaration", err)
+		return err
+	}
+	d.VerseText = lookupDeclarationVerse(d.Reference)
+
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		return err
+	}
+	d.ID = schedule.nextDeclarationID(declarations)
+	declarations = append(declarations, d)
+	if err := saveDeclarations(declarations); err != nil {
+		return err
+	}
+	schedule.LastDeclarationID = d.ID
+	if err := saveReviewSchedule(schedule); err != nil {
+		return err
+	}
+
+	fmt.Printf("Added declaration %d: %s\n", len(declarations), d)
+	return nil
+}
+
+// listDeclarations prints every declaration, or only those with the tag,
+// with its number
+func listDeclarations(tag string) error {
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for i, d := range declarations {
+		if len(tag) > 0 && !d.HasTag(tag) {
+			continue
+		}
+		found = true
+		theme.Muted.Printf("%3d. ", i+1)
+		fmt.Print(d)
+		if len(d.Tags) > 0 {
+			theme.Hint.Printf("  #%s", strings.Join(d.Tags, " #"))
+		}
+		fmt.Println()
+	}
+	if !found {
+		displayErrorText("No declarations found in " + config.DeclarationsFile)
+		return errNotFound
+	}
+	return nil
+}
+
+// editDeclaration replaces the text of declaration n (starting at 1).  The
+// reference is replaced only if the new text ends with its own reference and
+// the tags only if the new text has words starting with #.
+func editDeclaration(n int, args string) error {
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+	if err := checkDeclarationNumber(n, declarations); err != nil {
+		return err
+	}
+
+	d := declarations[n-1]
+	text, tags := splitHashTags(args)
+	text, reference := splitDeclaration(text)
+	d.Text = text
+	if len(reference) > 0 && canonicalReference(reference) != d.Reference {
+		d.Reference = canonicalReference(reference)
+		d.VerseText = lookupDeclarationVerse(d.Reference)
+	}
+	if len(tags) > 0 {
+		d.Tags = tags
+	}
+	if err := validateDeclaration(d); err != nil {
+		displayError("Unable to edit declaration", err)
+		return err
+	}
+
+	declarations[n-1] = d
+	if err := saveDeclarations(declarations); err != nil {
+		return err
+	}
+	fmt.Printf("Changed declaration %d: %s\n", n, d)
+	return nil
+}
+
+// tagDeclaration replaces the tags of declaration n (starting at 1)
+func tagDeclaration(n int, tags []string) error {
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+	if err := checkDeclarationNumber(n, declarations); err != nil {
+		return err
+	}
+
+	declarations[n-1].Tags = tags
+	if err := saveDeclarations(declarations); err != nil {
+		return err
+	}
+	fmt.Printf("Tagged declaration %d: %s\n", n, strings.Join(tags, " "))
+	return nil
+}
+
+// deleteDeclaration removes declaration n (starting at 1) and its review
+// state.  Its ID is not handed out again.
+func deleteDeclaration(n int) error {
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+	if err := checkDeclarationNumber(n, declarations); err != nil {
+		return err
+	}
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		return err
+	}
+
+	deleted := declarations[n-1]
+	schedule.LastDeclarationID = schedule.nextDeclarationID(declarations) - 1
+	declarations = append(declarations[:n-1], declarations[n:]...)
+	if err := saveDeclarations(declarations); err != nil {
+		return err
+	}
+	delete(schedule.Items, declarationKey(deleted.ID))
+	if err := saveReviewSchedule(schedule); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted declaration %d: %s\n", n, deleted)
+	return nil
+}
+
+// validateDeclarations lists the declarations that have no text or a
+// reference that cannot be parsed
+func validateDeclarations() error {
+	declarations, err := loadDeclarations()
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for i, d := range declarations {
+		if err := validateDeclaration(d); err != nil {
+			invalid++
+			theme.Error.Printf("%3d. %v\n", i+1, err)
+			fmt.Printf("     %s\n", d)
+		}
+	}
+	if invalid > 0 {
+		displayErrorText(fmt.Sprintf("%d of %d declarations are invalid", invalid, len(declarations)))
+		return errUsage
+	}
+	fmt.Printf("All %d declarations are valid\n", len(declarations))
+	return nil
+}
+
+// validateDeclaration returns an error if the declaration has no text or its
+// reference cannot be parsed
+func validateDeclaration(d Declaration) error {
+	if len(strings.TrimSpace(d.Text)) == 0 {
+		return errors.Wrap(errUsage, "The declaration text is empty")
+	}
+	if len(d.Reference) == 0 {
+		return errors.Wrap(errUsage, "Look up a verse first or end the declaration with its reference.  i.e. I stand in grace.  - Rom 5:2")
+	}
+	if _, err := ParseReferences(d.Reference); err != nil {
+		return errors.Wrap(errUsage, "Unknown verse reference: "+d.Reference)
+	}
+	return nil
+}
+
+// lookupDeclarationVerse returns the text of the verse for a declaration,
+// or nothing if it cannot be looked up
+func lookupDeclarationVerse(reference string) string {
+	passage, err := lookupVerse(reference, 0,
+		false, /*includeHeadings*/
+		false, /*includeFootnotes*/
+		false, /*indentPoetry*/
+		false /*includeVerseNumbers*/)
+	if err != nil || len(passage.Passages) == 0 {
+		debug("Unable to look up verse text for %s: %v\n", reference, err)
+		return ""
+	}
+
+	// Remove the reference on the first line and the copyright at the end
+	var texts []string
+	for _, p := range passage.Passages {
+		lines := newlineRegex.Split(strings.TrimSpace(p), -1)
+		texts = append(texts, strings.Join(lines[1:], " "))
+	}
+	text := strings.Join(texts, " ")
+	text = strings.Replace(text, "("+textProvider.Name()+")", "", 1)
+	return strings.TrimSpace(text)
+}
+
+// splitHashTags removes the words starting with # from the text and returns
+// them as tags
+func splitHashTags(args string) (text string, tags []string) {
+	for _, match := range hashTagRegex.FindAllStringSubmatch(args, -1) {
+		tags = append(tags, strings.ToLower(match[1]))
+	}
+	text = strings.TrimSpace(hashTagRegex.ReplaceAllString(args, ""))
+	return text, tags
+}
+
+// checkDeclarationNumber returns an error if n is not a declaration number
+func checkDeclarationNumber(n int, declarations []Declaration) error {
+	if n < 1 || n > len(declarations) {
+		displayErrorText(fmt.Sprintf("There is no declaration %d.  There are %d declarations.", n, len(declarations)))
+		return errUsage
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDeleteDeclarationIDNotReused(t *testing.T) {
+	dir := useDataDir(t)
+	savedFile := config.DeclarationsFile
+	config.DeclarationsFile = filepath.Join(dir, "declarations")
+	defer func() { config.DeclarationsFile = savedFile }()
+	savedProvider := textProvider
+	textProvider = newLocalProvider(filepath.Join(dir, "bible-text.txt"))
+	defer func() { textProvider = savedProvider }()
+
+	captureOutput(t, func() {
+		for _, text := range []string{"I stand in grace.  - Rom 5:2", "I am loved.  - 1 John 4:19"} {
+			if err := addDeclaration(text); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+
+	// Review declaration 2, then delete it
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schedule.item(declarationKey(2)).Interval = 30
+	if err := saveReviewSchedule(schedule); err != nil {
+		t.Fatal(err)
+	}
+	captureOutput(t, func() {
+		if err := deleteDeclaration(2); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if schedule, err = loadReviewSchedule(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schedule.Items[declarationKey(2)]; ok {
+		t.Error("the review state of the deleted declaration was kept")
+	}
+
+	// The next declaration gets a new ID
+	captureOutput(t, func() {
+		if err := addDeclaration("I am chosen.  - Eph 1:4"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	declarations, err := loadDeclarations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(declarations) != 2 || declarations[0].ID != 1 || declarations[1].ID != 3 {
+		t.Errorf("declarations are %+v, want IDs 1 and 3", declarations)
+	}
+}
@@ -1,7 +1,7 @@
 package main
 
 //
-// Generate a PDF of declarations from a file that has a declaration per line.
+// Generate a PDF of the declarations in the declarations file.
 //
 
 import (
@@ -19,7 +19,7 @@ const (
 
 // GeneratePdf generates our pdf by adding text to the page
 // then saving it to a file.
-func GeneratePdf(inputFilename, outputFilename string) error {
+func GeneratePdf(outputFilename string) error {
 
 	pdf := gofpdf.New("P", /* P=Portrait */
 		"mm",     /* mm = millimeters */
@@ -29,15 +29,13 @@ func GeneratePdf(inputFilename, outputFilename string) error {
 	pdf.AddPage()
 	pdf.SetFont("Arial", "", 10)
 
-	// Create a channel that will supply each line in the file
-	c, err := ReadLinesChannel(inputFilename)
+	declarations, err := loadDeclarations()
 	if err != nil {
 		return err
 	}
 
-	// Loop over each line in the declarations file
-	i := 0
-	for declaration := range c {
+	// Loop over each declaration
+	for i, declaration := range declarations {
 		if i > 0 {
 			// Provide an extra 2mm vertical space between declarations
 			pdf.Ln(2)
@@ -46,12 +44,10 @@ func GeneratePdf(inputFilename, outputFilename string) error {
 		// Write the declaration on however many lines are needed
 		pdf.MultiCell(pdfLineWidth, /* width */
 			pdfLineHeight, /* height */
-			declaration,
+			declaration.String(),
 			"0",   /* border 0=no-border */
 			"LM",  /* align LM=middle-left */
 			false) /* fill */
-
-		i++
 	}
 	fmt.Printf("Saved %d page(s) to ./%s\n", pdf.PageCount(), outputFilename)
 
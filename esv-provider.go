@@ -0,0 +1,151 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Looks up and searches passages with the ESV API at https://api.esv.org
+//
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	baseApiURL    = "https://api.esv.org/v3/passage/text/"
+	baseSearchUrl = "https://api.esv.org/v3/passage/search"
+
+	// searchPageSize is the most results the API returns in one page
+	searchPageSize = 100
+
+	// maxSearchPages limits the requests for a very common word
+	maxSearchPages = 20
+)
+
+// esvProvider is the TextProvider for the ESV API
+type esvProvider struct {
+	token  string
+	client *http.Client
+}
+
+func newESVProvider(token string) *esvProvider {
+	return &esvProvider{
+		token:  token,
+		client: &http.Client{Timeout: time.Second * 10},
+	}
+}
+
+// Name returns the name of the translation
+func (p *esvProvider) Name() string {
+	return "ESV"
+}
+
+// Passage returns the result of an HTTP REST request to the ESV scriptures
+// API. The ESV API supports returning multiple verses or even multiple passages
+// in one lookup request.
+//
+// The references are sent with full book names so the API does not have to
+// guess what we are looking for.
+func (p *esvProvider) Passage(refs []Reference, options PassageOptions) (*Passage, error) {
+	urlSafeVerseRef := url.QueryEscape(FormatReferences(refs))
+
+	requestURL := fmt.Sprintf(`%s?q=%s&line-length=%d&include-headings=%t&include-footnotes=%t&indent-poetry=%t&include-verse-numbers=%t`,
+		baseApiURL,
+		urlSafeVerseRef,
+		options.LineLength,
+		options.IncludeHeadings,
+		options.IncludeFootnotes,
+		options.IndentPoetry,
+		options.IncludeVerseNumbers)
+
+	jsonBody := Passage{}
+	if err := p.get(requestURL, &jsonBody); err != nil {
+		return nil, err
+	}
+	return &jsonBody, nil
+}
+
+// Search sends the searchString to the API and returns the first page of
+// results.  The other pages, up to maxSearchPages, are fetched as they are
+// needed.  The API cannot limit a search to some books, so the caller
+// filters the results.
+func (p *esvProvider) Search(searchString string) (*SearchResults, error) {
+	results, err := p.searchPage(searchString, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := 1
+	if page < results.TotalPages && page < maxSearchPages {
+		results.more = func() ([]Result, error) {
+			page++
+			next, err := p.searchPage(searchString, page)
+			if err != nil {
+				return nil, err
+			}
+			if page >= next.TotalPages || page >= maxSearchPages {
+				results.more = nil
+			}
+			return next.Results, nil
+		}
+	}
+	return results, nil
+}
+
+// searchPage returns one page of search results
+func (p *esvProvider) searchPage(searchString string, page int) (*SearchResults, error) {
+	requestURL := fmt.Sprintf(`%s?q=%s&page-size=%d&page=%d`, baseSearchUrl, url.QueryEscape(searchString), searchPageSize, page)
+	jsonBody := SearchResults{}
+	if err := p.get(requestURL, &jsonBody); err != nil {
+		return nil, err
+	}
+	return &jsonBody, nil
+}
+
+// get sends an authorized GET request to the API and decodes the JSON
+// response into jsonBody
+func (p *esvProvider) get(url string, jsonBody interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return errors.Wrap(err, "Error reading request.")
+	}
+
+	req.Header.Set("Authorization", "Token "+p.token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "Error reading response.")
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(jsonBody)
+	if err != nil {
+		return errors.Wrap(err, "Error reading response.")
+	}
+
+	return nil
+}
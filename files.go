@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"math/rand"
 	"os"
+	"path/filepath"
 	"regexp"
 	"time"
 )
@@ -12,37 +13,6 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-// grepRandom picks a random line from the file.  It does this by generating
-// a random number for each line and, when that number exceeds the previous
-// maximum, becomes the new line to be returned.
-func grepRandom(fileName string) (string, error) {
-
-	// This is the line to be returned and the random
-	// value it was assigned.  When a larger value is
-	// encountered the line is replaced with the new
-	// one.
-	var line string
-	var maxInt int
-
-	file, err := os.Open(fileName)
-	if err != nil {
-		return "", err
-	}
-
-	defer file.Close()
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		randomInt := rand.Intn(1000)
-		if randomInt > maxInt {
-			// We have a new line
-			line = scanner.Text()
-			maxInt = randomInt
-		}
-	}
-
-	return line, nil
-}
-
 // grep reads a file line by line and adds to the channel only lines
 // that match the given regexp.
 func grep(fileName string, regex *regexp.Regexp) (<-chan string, error) {
@@ -65,3 +35,60 @@ func grep(fileName string, regex *regexp.Regexp) (<-chan string, error) {
 
 	return c, nil
 }
+
+// ReadLines reads a whole text file into a slice of lines
+func ReadLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
+
+// WriteLinesAtomic writes the lines to a temporary file in the same directory
+// then renames it over the given file, so the file is never left half written.
+// The permissions of an existing file are kept.
+func WriteLinesAtomic(path string, lines []string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(tmp)
+	for _, line := range lines {
+		if _, err = writer.WriteString(line + "\n"); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+
+	// Close the file without defer so it can happen before Rename()
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
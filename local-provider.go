@@ -0,0 +1,229 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Looks up and searches Bible text in a local file so that no network is
+// needed.  The file has one verse per line: the reference (using the same
+// book names as the translation map file), a tab, then the text of the verse.
+// Lines starting with # are comments, except the first which may name the
+// translation.
+//
+//   # KJV
+//   Gen 1:1	In the beginning God created the heaven and the earth.
+//
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	wordwrap "github.com/mitchellh/go-wordwrap"
+	"github.com/pkg/errors"
+)
+
+var (
+	// localLineRegex matches the reference at the start of a line in the
+	// local text file.  i.e. "1Co 13:4"
+	localLineRegex = regexp.MustCompile(`^(.+) (\d+):(\d+)$`)
+)
+
+// localVerse is one verse read from the local text file
+type localVerse struct {
+	Book    Book
+	Chapter int
+	Verse   int
+	Text    string
+}
+
+// Reference returns the full reference of the verse.  i.e. "Romans 8:28"
+func (v localVerse) Reference() string {
+	return fmt.Sprintf("%s %d:%d", v.Book.FullName, v.Chapter, v.Verse)
+}
+
+// localProvider is the TextProvider for a local text file
+type localProvider struct {
+	fileName string
+	name     string
+
+	// verses are in the order of the file
+	verses []localVerse
+
+	// chapters holds the index into verses of each verse, keyed by the book
+	// TranslationName and chapter.  i.e. "Rom 8"
+	chapters map[string][]int
+
+	// index is the search index, loaded lazily by searchIndex
+	index *textIndex
+}
+
+func newLocalProvider(fileName string) *localProvider {
+	return &localProvider{fileName: fileName, name: "local"}
+}
+
+// Name returns the name of the translation from the first line of the file
+func (p *localProvider) Name() string {
+	p.load()
+	return p.name
+}
+
+// Passage returns the text of one or more verses, ranges or chapters.  Like
+// the ESV API, each passage in the reference is returned separately.
+func (p *localProvider) Passage(ranges []Reference, options PassageOptions) (*Passage, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+
+	passage := &Passage{}
+	var refs []string
+	for _, r := range ranges {
+		var verses []localVerse
+		for chapter := r.StartChapter; chapter <= r.EndChapter; chapter++ {
+			for _, ix := range p.chapters[chapterKey(r.Book, chapter)] {
+				if v := p.verses[ix]; r.Includes(v.Chapter, v.Verse) {
+					verses = append(verses, p.verses[ix])
+				}
+			}
+		}
+		if len(verses) == 0 {
+			continue
+		}
+		refs = append(refs, r.String())
+		passage.Passages = append(passage.Passages, p.format(r.String(), verses, options))
+	}
+	passage.VerseRef = strings.Join(refs, "; ")
+
+	return passage, nil
+}
+
+// Search returns the verses that match the query, using the index of the
+// local text.  See text-query.go for the query syntax.
+func (p *localProvider) Search(searchString string) (*SearchResults, error) {
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	index, err := p.searchIndex()
+	if err != nil {
+		return nil, errors.Wrap(err, "Error reading text index")
+	}
+	matches, err := searchTextIndex(index, len(p.verses), searchString)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &SearchResults{}
+	for _, verseIx := range matches.sortedVerses() {
+		v := p.verses[verseIx]
+		tokens := tokenize(v.Text)
+		var highlights [][2]int
+		for _, position := range matches[verseIx] {
+			highlights = append(highlights, [2]int{tokens[position].start, tokens[position].end})
+		}
+		results.Results = append(results.Results, Result{
+			Reference:  v.Reference(),
+			Content:    v.Text,
+			Highlights: highlights,
+		})
+	}
+	results.TotalResults = len(results.Results)
+	return results, nil
+}
+
+// format builds the passage text the way the ESV API does: the reference on
+// the first line, then the text followed by the translation name.
+func (p *localProvider) format(verseRef string, verses []localVerse, options PassageOptions) string {
+	var sb strings.Builder
+	for i, v := range verses {
+		if i > 0 {
+			sb.WriteString(" ")
+		}
+		if options.IncludeVerseNumbers {
+			sb.WriteString(fmt.Sprintf("[%d] ", v.Verse))
+		}
+		sb.WriteString(v.Text)
+	}
+	sb.WriteString(" (" + p.name + ")")
+
+	text := sb.String()
+	if options.LineLength > 0 {
+		text = wordwrap.WrapString(text, uint(options.LineLength))
+	}
+	return verseRef + "\n\n" + text
+}
+
+// load reads the local text file the first time it is needed
+func (p *localProvider) load() error {
+	if p.chapters != nil {
+		return nil
+	}
+
+	file, err := os.Open(p.fileName)
+	if err != nil {
+		return errors.Wrap(err, "Error opening local text file")
+	}
+	defer file.Close()
+
+	p.chapters = make(map[string][]int)
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			if lineNum == 1 {
+				p.name = strings.TrimSpace(line[1:])
+			}
+			continue
+		}
+
+		tabIx := strings.Index(line, "\t")
+		if tabIx < 0 {
+			continue
+		}
+		matches := localLineRegex.FindStringSubmatch(line[:tabIx])
+		if matches == nil {
+			debug("Skipping line %d of %s\n", lineNum, p.fileName)
+			continue
+		}
+		book, ok := findBook(matches[1])
+		if !ok {
+			debug("Unknown book on line %d of %s\n", lineNum, p.fileName)
+			continue
+		}
+		chapter, _ := strconv.Atoi(matches[2])
+		verse, _ := strconv.Atoi(matches[3])
+
+		key := chapterKey(book, chapter)
+		p.chapters[key] = append(p.chapters[key], len(p.verses))
+		p.verses = append(p.verses, localVerse{book, chapter, verse, line[tabIx+1:]})
+	}
+
+	return scanner.Err()
+}
+
+// chapterKey returns the key for a chapter of a book.  i.e. "Rom 8"
+func chapterKey(book Book, chapter int) string {
+	return book.TranslationName + " " + strconv.Itoa(chapter)
+}
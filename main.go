@@ -27,11 +27,13 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -51,6 +53,14 @@ const (
 var (
 	reader             = bufio.NewReader(os.Stdin)
 	nonNumericRegexp   = regexp.MustCompile(`[^0-9]`)
+	relatedRegex       = regexp.MustCompile(`^(r|related)(?:\s+([gh]\d+))?$`)
+	followRelatedRegex = regexp.MustCompile(`^r(\d+)$`)
+	memorizeRegex      = regexp.MustCompile(`^(mem|memorize)(?:\s+(.*))?$`)
+	concordanceRegex   = regexp.MustCompile(`^(cc|concordance)\s+([gh]\d+)$`)
+	strongsForRegex    = regexp.MustCompile(`^(sf|strongsfor)\s+(.+)$`)
+	crossRefsRegex     = regexp.MustCompile(`^(x|xref|xrefs)(?:\s+(.*))?$`)
+	translateRegex     = regexp.MustCompile(`^(t|tr|tran|trans|translate)(?:\s+(morph))?$`)
+	chapterVerseRegex  = regexp.MustCompile(`\d\s*[:.]\s*\d`)
 	previousPassageRef = ""
 	dataDirName        = ".biblestudy-data"
 	dataDirPath        string
@@ -65,29 +75,35 @@ var (
 func init() {
 	rand.Seed(time.Now().UnixNano())
 
-	//
-	// Possibly create the data directory
-	//
 	home, err := os.UserHomeDir()
 	if err != nil {
 		color.Red.Printf("Error finding home directory: %v\n", err)
 		os.Exit(1)
 	}
+	setDataDir(filepath.Join(home, dataDirName))
+}
 
-	dataDirPath = filepath.Join(home, dataDirName)
+// setDataDir sets the directory of the data files and the paths of the files
+// in it
+func setDataDir(path string) {
+	dataDirPath = path
 	translationMapFile = filepath.Join(dataDirPath, translationMapFileName)
 	strongsGreekFile = filepath.Join(dataDirPath, strongsGreekFileName)
 	strongsHebrewFile = filepath.Join(dataDirPath, strongsHebrewFileName)
+}
 
+// downloadDataFiles creates the data directory and downloads the translation
+// map and Strongs files if they are not there yet
+func downloadDataFiles() {
 	if _, err := os.Stat(dataDirPath); os.IsNotExist(err) {
 		os.Mkdir(dataDirPath, 0774)
-		fmt.Printf("Downloading data files to: %s\n", dataDirPath)
+		fmt.Fprintf(os.Stderr, "Downloading data files to: %s\n", dataDirPath)
 	}
 
 	if _, err := os.Stat(translationMapFile); os.IsNotExist(err) {
 		// Download TTESV, the translation file
 		if err := DownloadFile(translationMapFile, translationMapURL); err != nil {
-			color.Red.Printf("Error downloading url:\n  %s\n  %v\n", translationMapURL, err)
+			fmt.Fprint(os.Stderr, color.Red.Sprintf("Error downloading url:\n  %s\n  %v\n", translationMapURL, err))
 			os.Exit(1)
 		}
 	}
@@ -95,7 +111,7 @@ func init() {
 	if _, err := os.Stat(strongsGreekFile); os.IsNotExist(err) {
 		// Download the Strongs Greek definitions
 		if err := DownloadFile(strongsGreekFile, strongsGreekURL); err != nil {
-			color.Red.Printf("Error downloading url:\n  %s\n  %v\n", strongsGreekURL, err)
+			fmt.Fprint(os.Stderr, color.Red.Sprintf("Error downloading url:\n  %s\n  %v\n", strongsGreekURL, err))
 			os.Exit(1)
 		}
 	}
@@ -103,15 +119,42 @@ func init() {
 	if _, err := os.Stat(strongsHebrewFile); os.IsNotExist(err) {
 		// Download the Strongs Hebrew definitions
 		if err := DownloadFile(strongsHebrewFile, strongsHebrewURL); err != nil {
-			color.Red.Printf("Error downloading url:\n  %s\n  %v\n", strongsHebrewURL, err)
+			fmt.Fprint(os.Stderr, color.Red.Sprintf("Error downloading url:\n  %s\n  %v\n", strongsHebrewURL, err))
 			os.Exit(1)
 		}
 	}
 }
 
-// Keep looping until the user decides to quit
+// Keep looping until the user decides to quit, unless a subcommand was
+// given on the command line, in which case run it and exit.
 func main() {
+	downloadDataFiles()
+
+	args, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(exitOK)
+	} else if err != nil {
+		displayError("Error", err)
+		os.Exit(exitCode(err))
+	}
+
+	textProvider, err = selectTextProvider()
+	if err != nil {
+		displayError("Error choosing text provider", err)
+		os.Exit(exitCode(err))
+	}
+
+	if len(args) > 0 {
+		os.Exit(runSubcommand(args))
+	}
+
+	if ref, err := verseOfTheDay(time.Now()); err == nil {
+		theme.Muted.Printf("Verse of the day: %s", ref)
+		theme.Hint.Println("  (votd to show it)")
+	}
+
 	// Loop on the main prompt
+	interactive = true
 	for {
 		mainPrompt()
 	}
@@ -121,11 +164,15 @@ func mainPrompt() {
 
 	// home, err := os.UserHomeDir()
 	if len(previousPassageRef) > 0 {
-		color.FgDarkGray.Printf("Current verse: %s", previousPassageRef)
-		color.Cyan.Println("  (t)ranslate or (s)how it again")
+		theme.Muted.Printf("Current verse: %s", previousPassageRef)
+		theme.Hint.Println("  (t)ranslate, (x)refs, (s)how it again, (n)ext, (b)ack, nc or bc")
+	}
+	if len(previousStrongs) > 0 {
+		theme.Muted.Printf("Current strongs: %s", previousStrongs)
+		theme.Hint.Println("  (r)elated or r<n> to show related entry n")
 	}
-	color.Cyan.Println("Enter verse reference, strongs# (i.e. g4982 or h3068), (p)roverb, (d)eclaration, (h)elp or (q)uit.")
-	color.Magenta.Print(" > ")
+	theme.Hint.Println("Enter verse reference, strongs# (i.e. g4982 or h3068), (p)roverb, (d)eclaration, (h)elp or (q)uit.")
+	theme.Prompt.Print(" > ")
 	text, err := reader.ReadString('\n')
 	if err != nil {
 		fmt.Println("Error: ", err)
@@ -134,6 +181,7 @@ func mainPrompt() {
 
 	// Remove leading or trailing spaces and newline from the end of text
 	text = strings.TrimSpace(text)
+	rawText := text // for file names which are case sensitive
 	text = strings.ToLower(text)
 
 	// Try again if no data was entered
@@ -157,14 +205,66 @@ func mainPrompt() {
 	}
 
 	// Shall we exit?
-	exit, _ := regexp.MatchString(`^(exit|x|q|quit)$`, text)
+	exit, _ := regexp.MatchString(`^(exit|q|quit)$`, text)
 	if exit {
 		os.Exit(0)
 	}
 
-	// Search on the ESV text?
+	// Show the settings?
+	if text == "config" {
+		printConfig()
+		return
+	}
+
+	// Show or switch the text provider?
+	if text == "provider" || strings.HasPrefix(text, "provider ") {
+		name := strings.TrimSpace(text[8:])
+		if len(name) > 0 {
+			if err := switchTextProvider(name); err != nil {
+				displayError("Error switching text provider", err)
+				return
+			}
+		}
+		fmt.Printf("Text provider: %s\n", textProvider.Name())
+		return
+	}
+
+	// Import a translation into the local text file?
+	// Example: 'import ~/Downloads/kjv.osis.xml KJV'
+	if strings.HasPrefix(text, "import ") {
+		importTextFile(strings.Fields(rawText)[1:])
+		return
+	}
+
+	// Search on the Bible text?  The raw text is used so that the upper case
+	// operators (i.e. OR) are kept.
 	if strings.HasPrefix(text, "search ") {
-		displaySearchResults(text[7:])
+		displaySearchResults(rawText[7:])
+		return
+	}
+
+	// List the strongs numbers translated with an English word
+	// Example: 'strongsfor love' or 'sf holy spirit'
+	if matches := strongsForRegex.FindStringSubmatch(text); matches != nil {
+		strongsFor(matches[2])
+		return
+	}
+
+	// List the cross references of the latest passage
+	// Example: 'x', 'x 20', 'x all in epistles' or 'x show'
+	if matches := crossRefsRegex.FindStringSubmatch(text); matches != nil {
+		if len(previousPassageRef) == 0 {
+			displayErrorText("You have not looked up a verse to cross reference.")
+		} else {
+			crossReferences(previousPassageRef, matches[2])
+		}
+		return
+	}
+
+	// Show every English rendering of a strongs number
+	// Example: 'concordance g26' or 'cc h3068'
+	if matches := concordanceRegex.FindStringSubmatch(text); matches != nil {
+		concordance(matches[2])
 		return
 	}
 
@@ -172,7 +272,7 @@ func mainPrompt() {
 	// Example: 'g4982' or 'G4982'
 	strongsGreek, _ := regexp.MatchString(`^g\d+$`, text)
 	if strongsGreek {
-		displayStrongs(text, strongsGreekFile)
+		displayStrongs(text)
 		return
 	}
 
@@ -180,7 +280,30 @@ func mainPrompt() {
 	// Example: 'h7654' or 'H7654'
 	strongsHebrew, _ := regexp.MatchString(`^h\d+$`, text)
 	if strongsHebrew {
-		displayStrongs(text, strongsHebrewFile)
+		displayStrongs(text)
+		return
+	}
+
+	// List the entries related to a strongs number
+	// Example: 'related g4982' or just 'r' for the current strongs number
+	if matches := relatedRegex.FindStringSubmatch(text); matches != nil {
+		strongsNum := matches[2]
+		if len(strongsNum) == 0 {
+			strongsNum = previousStrongs
+		}
+		if len(strongsNum) == 0 {
+			displayErrorText("You have not looked up a strongs number.")
+		} else {
+			displayRelatedStrongs(strongsNum)
+		}
+		return
+	}
+
+	// Show a related entry of the current strongs number
+	// Example: 'r2'
+	if matches := followRelatedRegex.FindStringSubmatch(text); matches != nil {
+		n, _ := strconv.Atoi(matches[1])
+		followRelatedStrongs(n)
 		return
 	}
 
@@ -194,13 +317,13 @@ func mainPrompt() {
 		return
 	}
 
-	// Translate the latest verse to strongs numbers
-	translatePrev, _ := regexp.MatchString(`^(t|tr|tran|trans|translate)$`, text)
-	if translatePrev {
+	// Translate each verse of the latest passage to strongs numbers
+	// Example: 't' or 't morph' (with the morphology of each word)
+	if matches := translateRegex.FindStringSubmatch(text); matches != nil {
 		if len(previousPassageRef) == 0 {
 			displayErrorText("You have not looked up a verse to translate.")
 		} else {
-			translate(previousPassageRef)
+			translate(previousPassageRef, len(matches[2]) > 0)
 
 			fmt.Println()
 			fmt.Println("Find other verses that include a strongs number.  Example: g4982 search")
@@ -220,20 +343,73 @@ func mainPrompt() {
 		return
 	}
 
+	// Move to the next or previous verse or chapter
+	if navigateRegex.MatchString(text) {
+		navigate(text)
+		return
+	}
+
 	// Show a random proverb
 	proverb, _ := regexp.MatchString(`^(p|prov|proverb|proverbs)$`, text)
 	if proverb {
-		previousPassageRef = randomProverb()
+		if ref, err := randomProverb(); err == nil {
+			previousPassageRef = ref
+		}
 		return
 	}
 
-	// Show a random declaration.
+	// Show a random verse from the books matching the filter
+	// Example: 'random', 'random psalms' or 'random nt gospel'
+	if text == "random" || strings.HasPrefix(text, "random ") {
+		if ref, err := randomFiltered(text[6:]); err == nil {
+			previousPassageRef = ref
+		}
+		return
+	}
+
+	// Show the verse of the day, or of another date
+	// Example: 'votd' or 'votd 2020-12-25'
+	if text == "votd" || strings.HasPrefix(text, "votd ") {
+		if ref, err := runVotd(strings.Fields(text[4:])); err == nil {
+			previousPassageRef = ref
+		}
+		return
+	}
+
+	// List or download the tagged Greek and Hebrew files
+	// Example: 'morph' or 'morph download'
+	if text == "morph" || strings.HasPrefix(text, "morph ") {
+		runMorph(text[5:])
+		return
+	}
+
+	// Review the declarations and memory verses that are due
+	// Example: 'review', 'review declarations' or 'review verses'
+	if text == "review" || strings.HasPrefix(text, "review ") {
+		runReview(text[6:])
+		return
+	}
+
+	// Add the current verse to the memory verses, or list or delete them
+	// Example: 'mem', 'mem list' or 'mem delete 2'
+	if matches := memorizeRegex.FindStringSubmatch(text); matches != nil {
+		runMemorize(matches[2])
+		return
+	}
+
+	// Show a random declaration or manage the declarations.
 	// A declaration is a biblical truth or verse that you have reworded to help you
 	// internalize it as applying directly to you.
-	// i.e.
-	declaration, _ := regexp.MatchString(`^(d|declaration)$`, text)
-	if declaration {
-		displayRandomDeclaration()
+	// Example: 'd', 'd fear', 'da I stand in grace.' or 'declaration add I stand in grace.'
+	// The raw text is used so that the case of the declaration is kept.  Verse
+	// references like 'dt 6:4' or 'da 3:17' are not declaration commands, but
+	// 'dt 6' tags declaration 6.
+	if matches := declarationCommandPrefix.FindStringSubmatch(rawText); matches != nil && !isVerseReference(text) {
+		args := matches[2]
+		if action, ok := declarationAliases[strings.ToLower(matches[1])]; ok {
+			args = action + " " + args
+		}
+		runDeclarationCommand(args)
 		return
 	}
 
@@ -241,7 +417,7 @@ func mainPrompt() {
 	// pd=print declarations or pdf=pdf
 	generatePdf, _ := regexp.MatchString(`^(pd|pdf)$`, text)
 	if generatePdf {
-		err := GeneratePdf(declarationsFilename, declarationsPdfFilename)
+		err := GeneratePdf(config.DeclarationsPdf)
 		if err != nil {
 			displayError("Error:", err)
 		}
@@ -252,35 +428,74 @@ func mainPrompt() {
 	showVerse(text)
 }
 
+// isVerseReference returns true if the text is one or more verse references
+// with a chapter and verse.  i.e. "dt 6:4" but not "dt 6"
+func isVerseReference(text string) bool {
+	if !chapterVerseRegex.MatchString(text) {
+		return false
+	}
+	_, err := ParseReferences(text)
+	return err == nil
+}
+
 // showVerse looks up the reference and displays it on system out
-func showVerse(verseRef string) {
-	// Show the verse
-	book, _ := parseVerseRef(verseRef)
-	if book != "" {
-		previousPassageRef = displayPassage(verseRef,
-			true, /*includeHeadings*/
-			true, /*includeFootnotes*/
-			true, /*indentPoetry*/
-			true /*includeVerseNumbers*/)
-		return
+func showVerse(verseRef string) error {
+	passageRef, err := displayPassage(verseRef,
+		true, /*includeHeadings*/
+		true, /*includeFootnotes*/
+		true, /*indentPoetry*/
+		true /*includeVerseNumbers*/)
+	if err != nil {
+		return err
 	}
+	previousPassageRef = passageRef
+	return nil
 }
 
 func printHelpMainPrompt() {
 	fmt.Println("Need Help?  You can enter:")
 	fmt.Println("  a verse e.g. Ps3.3 or James 4:11")
-	fmt.Println("  t - translate the latest verse requested")
+	fmt.Println("  t - translate each verse of the latest passage requested")
+	fmt.Println("  t morph - translate with the tense, voice, mood, case, etc. of each Greek or Hebrew word")
+	fmt.Println("  morph download - download the tagged Greek and Hebrew files needed by t morph")
 	fmt.Println("  s - show text for the latest verse again")
+	fmt.Println("  n - next - show the verse after the latest verse")
+	fmt.Println("  b - back - show the verse before the latest verse")
+	fmt.Println("  nc - show the next chapter (bc shows the previous chapter)")
 	fmt.Println("  search rabble - search for the word 'rabble'")
-	fmt.Println("                - may not return all matches if too many verses")
 	fmt.Println("                - use quotes around phrases to limit search results")
+	fmt.Println("  search fear in nt - search only the books, categories or testaments after 'in'")
+	fmt.Println("  search fear -sort - show the results in the order of the books")
 	fmt.Println("  g<strongs> - strongs number prefixed by 'g' (for greek)   e.g. g2222")
 	fmt.Println("  h<strongs> - strongs number prefixed by 'h' (for hebrew)  e.g. h5555")
 	fmt.Println("  g<strongs> search epistles - searches on strongs num")
+	fmt.Println("  sf love - strongsfor - lists the strongs numbers translated with an English word")
+	fmt.Println("  x - xrefs - lists the cross references of the latest passage by votes")
+	fmt.Println("  x 20 in epistles - lists the top 20 cross references in the epistles (x show reads them)")
+	fmt.Println("  cc g26 - concordance - lists every English rendering of a strongs number")
+	fmt.Println("  r - related - lists the entries related to the latest strongs number")
+	fmt.Println("  r<n> - shows related entry n of the latest strongs number  e.g. r2")
+	fmt.Println("  related g4982 - lists the entries related to a strongs number")
 	fmt.Println("  p - proverb - prints a random proverb")
-	fmt.Println("  d - declaration - displays a random line from your declarations file")
+	fmt.Println("  d - declaration - displays a random declaration")
+	fmt.Println("  d fear - displays a random declaration tagged fear")
+	fmt.Println("  da I stand in grace. #identity - add a declaration for the latest verse requested")
+	fmt.Println("  dl [tag] - list the declarations with their numbers")
+	fmt.Println("  de 3 I stand in grace. - edit declaration 3")
+	fmt.Println("  dt 3 identity fear - replace the tags of declaration 3")
+	fmt.Println("  dd 3 - delete declaration 3")
+	fmt.Println("  dv - validate that each declaration has text and a verse reference")
+	fmt.Println("  votd - show the verse of the day (votd 2020-12-25 shows the verse for that date)")
+	fmt.Println("  random - show a random verse (random psalms, random epistles or random nt gospel)")
 	fmt.Println("  pd - print all declarations as printable pdf")
-	fmt.Println("  q - quit or x - exit")
+	fmt.Println("  mem - memorize - adds the latest verse requested to your memory verses")
+	fmt.Println("  mem list - lists your memory verses (mem delete 2 deletes one)")
+	fmt.Println("  review - reviews the declarations and memory verses that are due")
+	fmt.Println("  review declarations (or verses) - reviews only those that are due")
+	fmt.Println("  import kjv.xml [name] - import an OSIS, USFX or Zefania file for offline use")
+	fmt.Println("  config - show the settings and where they come from")
+	fmt.Println("  provider local - read Bible text from the local text file (or esv for the ESV API)")
+	fmt.Println("  q - quit or exit")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  > 2Tim 1.7             (shows text for 2 Tim 1:7)")
@@ -0,0 +1,101 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/gookit/color"
+)
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// TestMain points the data files at an empty directory so the tests never
+// read or download the files in the home directory
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "biblestudy-data")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	setDataDir(dir)
+	config = defaultConfig()
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useDataDir points the data files at a new directory for one test
+func useDataDir(t *testing.T) string {
+	saved := dataDirPath
+	dir := t.TempDir()
+	setDataDir(dir)
+	t.Cleanup(func() { setDataDir(saved) })
+	return dir
+}
+
+func TestIsVerseReference(t *testing.T) {
+	tests := map[string]bool{
+		"dt 6:4":         true,
+		"da 3:17":        true,
+		"de 5.1":         true,
+		"dt 6":           false,
+		"de 3":           false,
+		"dd 12":          false,
+		"da i am loved.": false,
+		"dt 2 #identity": false,
+	}
+	for text, want := range tests {
+		if got := isVerseReference(text); got != want {
+			t.Errorf("isVerseReference(%q) = %t, want %t", text, got, want)
+		}
+	}
+}
+
+// captureOutput returns what f prints to stdout, without colors
+func captureOutput(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	color.SetOutput(w)
+	output := make(chan []byte)
+	go func() {
+		out, _ := ioutil.ReadAll(r)
+		output <- out
+	}()
+	defer func() {
+		w.Close()
+		os.Stdout = saved
+		color.ResetOutput()
+	}()
+	f()
+
+	w.Close()
+	return ansiRegex.ReplaceAllString(string(<-output), "")
+}
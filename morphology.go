@@ -0,0 +1,480 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Shows the morphology (part of speech, tense, voice, mood, case, number and
+// gender) of the Greek and Hebrew words behind a translation.  It comes from
+// the tagged Greek NT (TAGNT) and Hebrew OT (TAHOT) files of STEPBible-Data,
+// which are large, so they are only downloaded with "morph download".  Each
+// word is on its own line, separated by tabs:
+//
+//	TAGNT:  Rom.8.28#07=NKO  ἀγαπῶσι (agapōsi)  loving  G0025=V-PAP-DPM  ...
+//	TAHOT:  Gen.1.1#01=L  בְּ/רֵאשִׁ֖ית  be./re.Shit  in/ beginning  H9003/{H7225G}  HR/Ncfsa  ...
+//
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+const morphURLPrefix = "https://github.com/STEPBible/STEPBible-Data/raw/master/Translators%20Amalgamated%20OT%2BNT/"
+
+// morphFile is one of the tagged Greek or Hebrew files and the books in it
+type morphFile struct {
+	FileName  string
+	URL       string
+	FirstBook int
+	LastBook  int
+}
+
+var morphFiles = []morphFile{
+	{"tahot-gen-deu.txt", morphURLPrefix + "TAHOT%20Gen-Deu%20-%20Translators%20Amalgamated%20Hebrew%20OT%20-%20STEPBible.org%20CC%20BY.txt", 1, 5},
+	{"tahot-jos-est.txt", morphURLPrefix + "TAHOT%20Jos-Est%20-%20Translators%20Amalgamated%20Hebrew%20OT%20-%20STEPBible.org%20CC%20BY.txt", 6, 17},
+	{"tahot-job-sng.txt", morphURLPrefix + "TAHOT%20Job-Sng%20-%20Translators%20Amalgamated%20Hebrew%20OT%20-%20STEPBible.org%20CC%20BY.txt", 18, 22},
+	{"tahot-isa-mal.txt", morphURLPrefix + "TAHOT%20Isa-Mal%20-%20Translators%20Amalgamated%20Hebrew%20OT%20-%20STEPBible.org%20CC%20BY.txt", 23, 39},
+	{"tagnt-mat-jhn.txt", morphURLPrefix + "TAGNT%20Mat-Jhn%20-%20Translators%20Amalgamated%20Greek%20NT%20-%20STEPBible.org%20CC-BY.txt", 40, 43},
+	{"tagnt-act-rev.txt", morphURLPrefix + "TAGNT%20Act-Rev%20-%20Translators%20Amalgamated%20Greek%20NT%20-%20STEPBible.org%20CC-BY.txt", 44, 66},
+}
+
+// morphWord is one Greek or Hebrew word of a verse.  Hebrew words may have
+// prefixes and suffixes, so each part has its own strongs number and code.
+type morphWord struct {
+	Text    string   // i.e. "ἀγαπῶσι (agapōsi)"
+	Strongs []string // i.e. "g25"
+	Codes   []string // i.e. "V-PAP-DPM"
+	Hebrew  bool
+}
+
+// runMorph shows which tagged files have been downloaded, or downloads the
+// missing ones when args is "download"
+func runMorph(args string) error {
+	switch strings.TrimSpace(args) {
+	case "":
+		for _, f := range morphFiles {
+			status := "not downloaded"
+			if info, err := os.Stat(filepath.Join(dataDirPath, f.FileName)); err == nil {
+				status = fmt.Sprintf("%d MB", info.Size()/1000000)
+			}
+			fmt.Printf("  %-18s %s\n", f.FileName, status)
+		}
+		theme.Hint.Println("Enter morph download to download the missing files, then t morph to use them")
+		return nil
+	case "download":
+		for _, f := range morphFiles {
+			fileName := filepath.Join(dataDirPath, f.FileName)
+			if _, err := os.Stat(fileName); err == nil {
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Downloading %s\n", f.FileName)
+			if err := DownloadFile(fileName, f.URL); err != nil {
+				displayError("Error downloading "+f.URL, err)
+				return err
+			}
+		}
+		fmt.Println("The tagged Greek and Hebrew files are in " + dataDirPath)
+		return nil
+	default:
+		displayErrorText("Enter morph to list the tagged Greek and Hebrew files or morph download to download them")
+		return errUsage
+	}
+}
+
+// addMorphology finds the Greek or Hebrew word behind each strongs number of
+// the alignments and adds its decoded morphology.  When a strongs number is
+// used more than once in a verse, its words are taken in order.
+func addMorphology(verses []Reference, alignments []verseAlignment) error {
+	words, err := loadMorphWords(verses)
+	if err != nil {
+		return err
+	}
+
+	for i := range alignments {
+		verseWords := words[verses[i].TranslationRef()]
+		used := map[string]int{}
+		for c := range alignments[i].Chunks {
+			chunk := &alignments[i].Chunks[c]
+			chunk.Morphology = make([]string, len(chunk.Strongs))
+			for s, number := range chunk.Strongs {
+				// Words that continue a mapping are the same Greek or Hebrew word
+				if !chunk.Continued {
+					used[number]++
+				}
+				chunk.Morphology[s] = findMorphology(verseWords, number, used[number])
+			}
+		}
+	}
+	return nil
+}
+
+// findMorphology returns the text and decoded morphology of the nth (from
+// 1) word of the verse with the strongs number
+func findMorphology(words []morphWord, strongsNum string, n int) string {
+	for _, word := range words {
+		for i, number := range word.Strongs {
+			if number != strongsNum {
+				continue
+			}
+			if n--; n > 0 {
+				continue
+			}
+			if word.Hebrew {
+				return word.Text + " " + decodeHebrewMorphology(word.Codes[i])
+			}
+			return word.Text + " " + decodeGreekMorphology(word.Codes[i])
+		}
+	}
+	return ""
+}
+
+// loadMorphWords reads the words of the verses from the tagged files, keyed
+// by their translation reference.  i.e. "Rom 8:28"
+func loadMorphWords(verses []Reference) (map[string][]morphWord, error) {
+	// Look in each file once for all of its verses
+	wanted := map[string]map[string]string{}
+	for _, verse := range verses {
+		f, ok := morphFileFor(verse.Book)
+		if !ok {
+			continue
+		}
+		if wanted[f.FileName] == nil {
+			wanted[f.FileName] = map[string]string{}
+		}
+		key := fmt.Sprintf("%s.%d.%d", stepBookID(verse.Book), verse.StartChapter, verse.StartVerse)
+		wanted[f.FileName][key] = verse.TranslationRef()
+	}
+
+	words := map[string][]morphWord{}
+	for fileName, refs := range wanted {
+		path := filepath.Join(dataDirPath, fileName)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			displayErrorText("The tagged Greek and Hebrew files have not been downloaded.  Enter morph download to download them.")
+			return nil, errNotFound
+		}
+		if err := readMorphFile(path, refs, words); err != nil {
+			displayError("Error reading "+path, err)
+			return nil, err
+		}
+	}
+	return words, nil
+}
+
+// readMorphFile adds the words of the wanted verses in the file to words.
+// wanted maps the STEP reference of each verse to its translation reference.
+func readMorphFile(fileName string, wanted map[string]string, words map[string][]morphWord) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hebrew := strings.HasPrefix(filepath.Base(fileName), "tahot")
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		hashIx := strings.Index(line, "#")
+		if hashIx <= 0 {
+			continue
+		}
+		// The Hebrew verse number may follow in parentheses.  i.e. "Gen.31.55(32.1)"
+		stepRef := line[:hashIx]
+		if ix := strings.Index(stepRef, "("); ix >= 0 {
+			stepRef = stepRef[:ix]
+		}
+		verseRef, ok := wanted[stepRef]
+		if !ok {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		word, ok := parseMorphWord(fields, hebrew)
+		if ok {
+			words[verseRef] = append(words[verseRef], word)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseMorphWord reads the fields of a TAGNT or TAHOT word line
+func parseMorphWord(fields []string, hebrew bool) (morphWord, bool) {
+	word := morphWord{Hebrew: hebrew}
+	if hebrew {
+		// Hebrew, transliteration, English, strongs, grammar.  The parts of
+		// the word are separated by slashes and the grammar starts with the
+		// language.  i.e. "H9003/{H7225G}" and "HR/Ncfsa"
+		if len(fields) < 6 || len(fields[5]) < 2 {
+			return word, false
+		}
+		word.Text = fmt.Sprintf("%s (%s)", strings.ReplaceAll(fields[1], "/", ""), strings.ReplaceAll(fields[2], "/", ""))
+		language := fields[5][:1]
+		for _, number := range strings.Split(fields[4], "/") {
+			word.Strongs = append(word.Strongs, morphStrongsKey(number))
+		}
+		for _, code := range strings.Split(fields[5][1:], "/") {
+			word.Codes = append(word.Codes, language+code)
+		}
+		if len(word.Codes) != len(word.Strongs) {
+			return word, false
+		}
+		return word, true
+	}
+
+	// Greek (transliteration), English, strongs=grammar.  i.e. "G0025=V-PAP-DPM"
+	if len(fields) < 4 {
+		return word, false
+	}
+	eqIx := strings.Index(fields[3], "=")
+	if eqIx < 0 {
+		return word, false
+	}
+	word.Text = strings.TrimSpace(fields[1])
+	word.Strongs = []string{morphStrongsKey(fields[3][:eqIx])}
+	word.Codes = []string{strings.TrimSpace(fields[3][eqIx+1:])}
+	return word, true
+}
+
+// morphStrongsKey converts a STEP strongs number like "G0025" or "{H7225G}"
+// into the form used by the translation map.  i.e. "g25" or "h7225"
+func morphStrongsKey(number string) string {
+	number = strings.Trim(strings.TrimSpace(number), "{}")
+	if len(number) < 2 {
+		return strings.ToLower(number)
+	}
+	return strongsKey(number[:1] + leadingDigits(number[1:]))
+}
+
+// leadingDigits returns the digits at the start of the string
+func leadingDigits(s string) string {
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// morphFileFor returns the tagged file holding the book
+func morphFileFor(book Book) (morphFile, bool) {
+	number := book.Number()
+	for _, f := range morphFiles {
+		if number >= f.FirstBook && number <= f.LastBook {
+			return f, true
+		}
+	}
+	return morphFile{}, false
+}
+
+// stepBookID returns the STEPBible name of the book, which is the USFM code
+// with its first letter capitalized.  i.e. "Sng" for Song of Solomon or "1Co"
+// for 1 Corinthians
+func stepBookID(book Book) string {
+	id := strings.ToLower(usfmBookIDs[book.Number()-1])
+	ix := strings.IndexFunc(id, unicode.IsLetter)
+	return id[:ix] + strings.ToUpper(id[ix:ix+1]) + id[ix+1:]
+}
+
+// Greek morphology codes (Robinson).  i.e. "V-PAP-DPM" or "N-NSF"
+var (
+	greekPartsOfSpeech = map[string]string{
+		"N": "noun", "V": "verb", "A": "adjective", "T": "article",
+		"P": "personal pronoun", "R": "relative pronoun", "C": "reciprocal pronoun",
+		"D": "demonstrative pronoun", "K": "correlative pronoun", "I": "interrogative pronoun",
+		"X": "indefinite pronoun", "Q": "correlative or interrogative pronoun",
+		"F": "reflexive pronoun", "S": "possessive pronoun",
+		"ADV": "adverb", "CONJ": "conjunction", "COND": "conditional", "PRT": "particle",
+		"PREP": "preposition", "INJ": "interjection", "ARAM": "Aramaic word", "HEB": "Hebrew word",
+	}
+	greekTenses = map[byte]string{
+		'P': "present", 'I': "imperfect", 'F': "future", 'A': "aorist", 'R': "perfect", 'L': "pluperfect",
+	}
+	greekVoices = map[byte]string{
+		'A': "active", 'M': "middle", 'P': "passive", 'E': "middle or passive",
+		'D': "middle deponent", 'O': "passive deponent", 'N': "middle or passive deponent",
+		'Q': "impersonal active", 'X': "no voice",
+	}
+	greekMoods = map[byte]string{
+		'I': "indicative", 'S': "subjunctive", 'O': "optative", 'M': "imperative",
+		'N': "infinitive", 'P': "participle", 'R': "imperative participle",
+	}
+	greekCases = map[byte]string{
+		'N': "nominative", 'G': "genitive", 'D': "dative", 'A': "accusative", 'V': "vocative",
+	}
+	numbers = map[byte]string{
+		'S': "singular", 'P': "plural", 's': "singular", 'p': "plural", 'd': "dual",
+	}
+	greekGenders = map[byte]string{
+		'M': "masculine", 'F': "feminine", 'N': "neuter",
+	}
+	persons = map[byte]string{
+		'1': "1st person", '2': "2nd person", '3': "3rd person",
+	}
+	greekSuffixes = map[string]string{
+		"C": "comparative", "S": "superlative", "N": "negative", "I": "interrogative",
+		"PRI": "indeclinable proper noun", "NUI": "indeclinable number", "LI": "indeclinable letter",
+		"OI": "indeclinable",
+	}
+)
+
+// decodeGreekMorphology describes a Robinson morphology code.  Codes it does
+// not understand are returned as they are.
+func decodeGreekMorphology(code string) string {
+	parts := strings.Split(code, "-")
+	partOfSpeech, ok := greekPartsOfSpeech[parts[0]]
+	if !ok {
+		return code
+	}
+	description := []string{partOfSpeech}
+	for i, part := range parts[1:] {
+		switch {
+		case len(part) == 0:
+			// An empty part.  i.e. "N-" or "V--"
+			continue
+		case greekSuffixes[part] != "":
+			description = append(description, greekSuffixes[part])
+		case parts[0] == "V" && i == 0:
+			// Tense, voice and mood.  i.e. "AAI" or "2AAI" (second aorist)
+			if strings.HasPrefix(part, "2") {
+				description = append(description, "second")
+				part = part[1:]
+			}
+			description = append(description, decodeLetters(part, greekTenses, greekVoices, greekMoods)...)
+		case part[0] >= '1' && part[0] <= '3' && len(part) <= 2:
+			// Person and number.  i.e. "3S"
+			description = append(description, decodeLetters(part, persons, numbers)...)
+		case part[0] >= '1' && part[0] <= '3' && parts[0] == "S":
+			// Person and number of the possessor, then case, number and gender.
+			// i.e. "1SNSM"
+			description = append(description, decodeLetters(part[:2], persons, numbers)...)
+			description = append(description, decodeLetters(part[2:], greekCases, numbers, greekGenders)...)
+		case part[0] >= '1' && part[0] <= '3':
+			// Person, then case, number and gender.  i.e. "3GSM"
+			description = append(description, persons[part[0]])
+			description = append(description, decodeLetters(part[1:], greekCases, numbers, greekGenders)...)
+		default:
+			// Case, number and gender.  i.e. "NSF"
+			description = append(description, decodeLetters(part, greekCases, numbers, greekGenders)...)
+		}
+	}
+	return strings.Join(description, " ")
+}
+
+// Hebrew and Aramaic morphology codes (OpenScriptures).  i.e. "HR/Ncfsa"
+var (
+	hebrewPartsOfSpeech = map[byte]string{
+		'A': "adjective", 'C': "conjunction", 'D': "adverb", 'N': "noun", 'P': "pronoun",
+		'R': "preposition", 'S': "suffix", 'T': "particle", 'V': "verb",
+	}
+	hebrewTypes = map[byte]map[byte]string{
+		'A': {'a': "", 'c': "cardinal number", 'g': "gentilic", 'o': "ordinal number"},
+		'N': {'c': "common", 'g': "gentilic", 'p': "proper"},
+		'P': {'d': "demonstrative", 'f': "indefinite", 'i': "interrogative", 'p': "personal", 'r': "relative"},
+		'R': {'d': "with the definite article"},
+		'S': {'d': "directional he", 'h': "paragogic he", 'n': "paragogic nun", 'p': "pronominal"},
+		'T': {'a': "affirmation", 'd': "definite article", 'e': "exhortation", 'i': "interrogative",
+			'j': "interjection", 'm': "demonstrative", 'n': "negative", 'o': "direct object marker", 'r': "relative"},
+	}
+	hebrewStems = map[byte]string{
+		'q': "qal", 'N': "niphal", 'p': "piel", 'P': "pual", 'h': "hiphil", 'H': "hophal",
+		't': "hithpael", 'o': "polel", 'O': "polal", 'r': "hithpolel", 'm': "poel", 'M': "poal",
+		'k': "palel", 'K': "pulal", 'Q': "qal passive", 'l': "pilpel", 'L': "polpal",
+		'f': "hithpalpel", 'D': "nithpael", 'j': "pealal", 'i': "pilel", 'u': "hothpaal",
+		'c': "tiphil", 'v': "hishtaphel", 'w': "nithpalel", 'y': "nithpoel", 'z': "hithpoel",
+	}
+	aramaicStems = map[byte]string{
+		'q': "peal", 'Q': "peil", 'u': "hithpeel", 'p': "pael", 'P': "ithpaal", 'M': "hithpaal",
+		'a': "aphel", 'h': "haphel", 's': "saphel", 'e': "shaphel", 'H': "hophal", 'i': "ithpeel",
+		't': "hishtaphel", 'v': "ishtaphal", 'w': "hithaphel", 'o': "polel", 'z': "ithpoel",
+		'r': "hithpolel", 'f': "hithpalpel", 'b': "hephal", 'c': "tiphel", 'm': "poel",
+		'l': "pilpel", 'L': "ithpilpel", 'O': "ithpolel", 'G': "ittaphal",
+	}
+	hebrewConjugations = map[byte]string{
+		'p': "perfect", 'q': "sequential perfect", 'i': "imperfect", 'w': "sequential imperfect",
+		'h': "cohortative", 'j': "jussive", 'v': "imperative", 'r': "active participle",
+		's': "passive participle", 'a': "infinitive absolute", 'c': "infinitive construct",
+	}
+	hebrewGenders = map[byte]string{
+		'b': "both genders", 'c': "common", 'f': "feminine", 'm': "masculine",
+		'l': "location", 't': "title",
+	}
+	hebrewStates = map[byte]string{
+		'a': "absolute", 'c': "construct", 'd': "determined",
+	}
+)
+
+// decodeHebrewMorphology describes one part of an OpenScriptures morphology
+// code, starting with its language.  i.e. "HVqp3ms" or "ANcmsd"
+func decodeHebrewMorphology(code string) string {
+	if len(code) < 2 {
+		return code
+	}
+	stems := hebrewStems
+	if code[0] == 'A' {
+		stems = aramaicStems
+	}
+	code = code[1:]
+	partOfSpeech, ok := hebrewPartsOfSpeech[code[0]]
+	if !ok {
+		return code
+	}
+	description := []string{partOfSpeech}
+	rest := code[1:]
+
+	switch code[0] {
+	case 'V':
+		// Stem and conjugation, then person, gender and number, or gender,
+		// number and state for participles.  i.e. "qp3ms" or "qrmsa"
+		description = append(description, decodeLetters(rest, stems, hebrewConjugations)...)
+		if len(rest) > 2 {
+			if rest[1] == 'r' || rest[1] == 's' {
+				description = append(description, decodeLetters(rest[2:], hebrewGenders, numbers, hebrewStates)...)
+			} else {
+				description = append(description, decodeLetters(rest[2:], persons, hebrewGenders, numbers)...)
+			}
+		}
+	case 'P', 'S':
+		// Type, person, gender and number.  i.e. "p3ms"
+		description = append(description, decodeLetters(rest, hebrewTypes[code[0]], persons, hebrewGenders, numbers)...)
+	case 'A', 'N':
+		// Type, gender, number and state.  i.e. "cfsa"
+		description = append(description, decodeLetters(rest, hebrewTypes[code[0]], hebrewGenders, numbers, hebrewStates)...)
+	default:
+		description = append(description, decodeLetters(rest, hebrewTypes[code[0]])...)
+	}
+	return strings.Join(description, " ")
+}
+
+// decodeLetters describes each letter of the code using the table at the
+// same position.  Letters that are not in their table are skipped.
+func decodeLetters(code string, tables ...map[byte]string) []string {
+	var words []string
+	for i := 0; i < len(code) && i < len(tables); i++ {
+		if word := tables[i][code[i]]; len(word) > 0 {
+			words = append(words, word)
+		}
+	}
+	return words
+}
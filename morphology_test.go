@@ -0,0 +1,86 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestStepBookID(t *testing.T) {
+	tests := map[string]string{
+		"Genesis":         "Gen",
+		"Song of Solomon": "Sng",
+		"1 Samuel":        "1Sa",
+		"1 Corinthians":   "1Co",
+		"1 John":          "1Jn",
+		"Revelation":      "Rev",
+	}
+	for name, want := range tests {
+		book, ok := findBook(name)
+		if !ok {
+			t.Fatalf("findBook(%q) failed", name)
+		}
+		if got := stepBookID(book); got != want {
+			t.Errorf("stepBookID(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLoadMorphWordsNumberedBook(t *testing.T) {
+	dir := useDataDir(t)
+
+	line := "1Co.13.4#01=NKO\tἡ (hē)\tthe\tG3588=T-NSF\tὁ=the\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "tagnt-act-rev.txt"), []byte(line), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ref, err := ParseReference("1 Cor 13:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	words, err := loadMorphWords([]Reference{ref})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := findMorphology(words[ref.TranslationRef()], "g3588", 1)
+	if want := "ἡ (hē) article nominative singular feminine"; got != want {
+		t.Errorf("findMorphology = %q, want %q", got, want)
+	}
+}
+
+func TestDecodeGreekMorphology(t *testing.T) {
+	tests := map[string]string{
+		"T-NSF":     "article nominative singular feminine",
+		"V-PAP-DPM": "verb present active participle dative plural masculine",
+		"N-":        "noun",
+		"V--":       "verb",
+		"V-2AAI-3S": "verb second aorist active indicative 3rd person singular",
+		"":          "",
+	}
+	for code, want := range tests {
+		if got := decodeGreekMorphology(code); got != want {
+			t.Errorf("decodeGreekMorphology(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Moves from the current passage to the next or previous verse or chapter,
+// crossing from one book to the next.
+//
+
+import (
+	"regexp"
+)
+
+var navigateRegex = regexp.MustCompile(`^(n|next|b|back|nc|bc)$`)
+
+// navigate shows the verse or chapter next to the current passage.
+// The command is n (next verse), b (back a verse), nc (next chapter) or bc
+// (back a chapter).
+func navigate(command string) error {
+	if len(previousPassageRef) == 0 {
+		displayErrorText("You have not looked up a verse to move from.")
+		return errUsage
+	}
+	refs, err := ParseReferences(previousPassageRef)
+	if err != nil {
+		displayErrorText(err.Error())
+		return err
+	}
+
+	var ref Reference
+	var ok bool
+	switch command {
+	case "n", "next":
+		ref, ok = refs[len(refs)-1].NextVerse()
+	case "b", "back":
+		ref, ok = refs[0].PreviousVerse()
+	case "nc":
+		ref, ok = refs[len(refs)-1].NextChapter()
+	case "bc":
+		ref, ok = refs[0].PreviousChapter()
+	}
+	if !ok {
+		if command[0] == 'n' {
+			displayErrorText("You are at the end of the Bible.")
+		} else {
+			displayErrorText("You are at the beginning of the Bible.")
+		}
+		return errNotFound
+	}
+	return showVerse(ref.String())
+}
+
+// NextVerse returns the verse after the end of the reference
+func (r Reference) NextVerse() (Reference, bool) {
+	book, chapter, verse := r.Book, r.EndChapter, r.EndVerse
+	if verse == 0 {
+		verse = book.VerseCount(chapter)
+	}
+
+	switch {
+	case verse < book.VerseCount(chapter):
+		verse++
+	case chapter < len(book.Chapters()):
+		chapter, verse = chapter+1, 1
+	default:
+		next, ok := bookAfter(book, 1)
+		if !ok {
+			return r, false
+		}
+		book, chapter, verse = next, 1, 1
+	}
+	return Reference{book, chapter, verse, chapter, verse}, true
+}
+
+// PreviousVerse returns the verse before the start of the reference
+func (r Reference) PreviousVerse() (Reference, bool) {
+	book, chapter, verse := r.Book, r.StartChapter, r.StartVerse
+	if verse == 0 {
+		verse = 1
+	}
+
+	switch {
+	case verse > 1:
+		verse--
+	case chapter > 1:
+		chapter--
+		verse = book.VerseCount(chapter)
+	default:
+		previous, ok := bookAfter(book, -1)
+		if !ok {
+			return r, false
+		}
+		book = previous
+		chapter = len(book.Chapters())
+		verse = book.VerseCount(chapter)
+	}
+	return Reference{book, chapter, verse, chapter, verse}, true
+}
+
+// NextChapter returns the whole chapter after the end of the reference
+func (r Reference) NextChapter() (Reference, bool) {
+	book, chapter := r.Book, r.EndChapter+1
+	if chapter > len(book.Chapters()) {
+		next, ok := bookAfter(book, 1)
+		if !ok {
+			return r, false
+		}
+		book, chapter = next, 1
+	}
+	return Reference{book, chapter, 0, chapter, 0}, true
+}
+
+// PreviousChapter returns the whole chapter before the start of the
+// reference
+func (r Reference) PreviousChapter() (Reference, bool) {
+	book, chapter := r.Book, r.StartChapter-1
+	if chapter < 1 {
+		previous, ok := bookAfter(book, -1)
+		if !ok {
+			return r, false
+		}
+		book, chapter = previous, len(previous.Chapters())
+	}
+	return Reference{book, chapter, 0, chapter, 0}, true
+}
+
+// bookAfter returns the book offset books after the given one (use -1 for
+// the book before it).  ok is false past either end of the Bible.
+func bookAfter(book Book, offset int) (Book, bool) {
+	i := book.Number() - 1 + offset
+	if i < 0 || i >= len(books) {
+		return book, false
+	}
+	return books[i], true
+}
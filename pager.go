@@ -0,0 +1,146 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Pages through a long list of results at the interactive prompt.  When run
+// as a subcommand every page is shown, one after another.
+//
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// pageSize is the number of results on each page.  It is changed with
+	// the size command while paging and lasts until the program exits.
+	pageSize = 20
+
+	// interactive is true when reading commands from the prompt rather than
+	// running a subcommand
+	interactive = false
+
+	pageSizeRegex = regexp.MustCompile(`^size\s+(\d+)$`)
+)
+
+// runPager shows the results a page at a time.  showPage is given the start
+// and end index of the results to show.
+func runPager(total int, showPage func(start, end int) error) error {
+	return runLoadingPager(func(int) (int, bool, error) { return total, true, nil }, showPage)
+}
+
+// runLoadingPager is runPager for results that are loaded as they are
+// needed.  load is asked for at least the given number of results and
+// returns how many there are now and whether that is all of them.
+func runLoadingPager(load func(need int) (total int, complete bool, err error), showPage func(start, end int) error) error {
+	page := 0
+	for {
+		// Ask for one more than the page to know if there is a next page
+		total, complete, err := load((page+1)*pageSize + 1)
+		if err != nil {
+			return err
+		}
+		numPages := (total + pageSize - 1) / pageSize
+		if page >= numPages {
+			page = numPages - 1
+		}
+		if page < 0 {
+			return nil
+		}
+		start := page * pageSize
+		end := start + pageSize
+		if end > total {
+			end = total
+		}
+		if err := showPage(start, end); err != nil {
+			return err
+		}
+
+		if numPages == 1 {
+			return nil
+		}
+		if !interactive {
+			if page++; page == numPages {
+				return nil
+			}
+			continue
+		}
+
+		var ok bool
+		page, ok = readPage(page, numPages, start, end, total, complete)
+		if !ok {
+			return nil
+		}
+	}
+}
+
+// readPage asks which page to show next.  ok is false when the user is done.
+// If the results are not complete, there may be more pages than numPages.
+func readPage(page, numPages, start, end, total int, complete bool) (newPage int, ok bool) {
+	more := ""
+	if !complete {
+		more = "+"
+	}
+	for {
+		theme.Muted.Printf("Page %d of %d%s (%d-%d of %d%s)", page+1, numPages, more, start+1, end, total, more)
+		theme.Hint.Print("  (n)ext, (b)ack, a page number, size <n>, or enter to stop")
+		theme.Prompt.Print(" > ")
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			return page, false
+		}
+		text = strings.ToLower(strings.TrimSpace(text))
+
+		switch {
+		case text == "" || text == "q" || text == "quit":
+			return page, false
+		case text == "n" || text == "next":
+			if page+1 < numPages {
+				return page + 1, true
+			}
+			displayErrorText("This is the last page.")
+		case text == "b" || text == "back":
+			if page > 0 {
+				return page - 1, true
+			}
+			displayErrorText("This is the first page.")
+		case pageSizeRegex.MatchString(text):
+			size, _ := strconv.Atoi(pageSizeRegex.FindStringSubmatch(text)[1])
+			if size < 1 {
+				displayErrorText("The page size must be at least 1.")
+				continue
+			}
+			// Stay on the page holding the first result shown
+			pageSize = size
+			return start / pageSize, true
+		default:
+			n, err := strconv.Atoi(text)
+			if err == nil && n >= 1 && n <= numPages {
+				return n - 1, true
+			}
+			displayErrorText(fmt.Sprintf("Enter n, b, a page number from 1 to %d, size <n>, or enter to stop.", numPages))
+		}
+	}
+}
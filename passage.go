@@ -1,120 +1,125 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"math/rand"
-	"net/http"
-	"regexp"
 	"strings"
-	"time"
-
-	"github.com/pkg/errors"
-)
-
-var (
-	verseRegexp            = regexp.MustCompile(`([0-9]*\s*[A-Za-z]+)\s*([0-9]+)[:.]?([0-9]*)`)
-	baseApiURL             = "https://api.esv.org/v3/passage/text/"
-	proverbsChapterLengths = []int{
-		33, 22, 35, 27, 23, 35, 27, 36, 18, 32,
-		31, 28, 25, 35, 33, 33, 28, 24, 29, 30,
-		31, 29, 35, 34, 28, 28, 27, 28, 27, 33,
-		31,
-	}
 )
 
 // randomProverb prints a random verse from Proverbs
-func randomProverb() string {
-	chapter := rand.Intn(len(proverbsChapterLengths))
-	//fmt.Printf("numChapters:%d ix:%d\n", len(proverbsChapterLengths), ix)
-	verse := rand.Intn(proverbsChapterLengths[chapter] + 1)
-	reference := fmt.Sprintf("Proverbs %d:%d", chapter, verse)
-	return displayPassage(reference,
+func randomProverb() (string, error) {
+	reference := randomVerse(bookNameMap["proverbs"])
+	return displayPassage(reference.String(),
 		false, /*includeHeadings*/
 		false, /*includeFootnotes*/
 		false, /*indentPoetry*/
 		false /*includeVerseNumbers*/)
 }
 
-// parseVerseRef parses the input string into book and chapterAndVerse
-func parseVerseRef(text string) (book string, chapterAndVerse string) {
-	if verseRegexp.MatchString(text) {
-		book = verseRegexp.ReplaceAllString(text, "$1")
-		chapterAndVerse = verseRegexp.ReplaceAllString(text, "$2")
-		return
+// randomFiltered prints a random verse from the books that match every word
+// of the filter, using the same words as a strongs search.  i.e. "psalms",
+// "epistles" or "nt gospel".  An empty filter matches every book.
+func randomFiltered(filter string) (string, error) {
+	filter = strings.ToLower(strings.TrimSpace(filter))
+	names := []string{}
+	if len(filter) == 0 {
+		for _, book := range books {
+			names = append(names, book.TranslationName)
+		}
+	} else if bookNames, ok := filters[filter]; ok {
+		// A name with spaces.  i.e. "1 john" or "song of solomon"
+		names = bookNames
+	} else {
+		words := strings.Fields(filter)
+		for _, word := range words {
+			if _, ok := filters[word]; !ok {
+				displayErrorText(fmt.Sprintf("%q is not a book, category or testament", word))
+				return "", errUsage
+			}
+		}
+		names = *findBooksMatchingWords(words)
 	}
-	return "", ""
-}
 
-// Print out the passage from the reference given
-func displayPassage(passageRef string, includeHeadings, includeFootnotes, indentPoetry, includeVerseNumbers bool) (cleanPassageRef string) {
-	passage, err := lookupVerse(passageRef, 80,
-		includeHeadings,
-		includeFootnotes,
-		indentPoetry,
-		includeVerseNumbers)
-	if err != nil {
-		displayError("Error looking up verse: ", err)
-		return
+	var matching []Book
+	for _, name := range names {
+		matching = append(matching, bookNameMap[strings.ToLower(name)])
 	}
-
-	if len(passage.Passages) == 0 {
-		displayErrorText("Passage not found")
-	} else {
-		cleanPassageRef = passage.VerseRef
-		for _, passageText := range passage.Passages {
-			//fmt.Println("============================================================")
-			fmt.Println(passageText)
-		}
+	if len(matching) == 0 {
+		displayErrorText("No books match " + filter)
+		return "", errNotFound
 	}
 
-	return cleanPassageRef
+	return displayPassage(randomVerse(matching...).String(),
+		false, /*includeHeadings*/
+		false, /*includeFootnotes*/
+		false, /*indentPoetry*/
+		true /*includeVerseNumbers*/)
 }
 
-// lookupVerse returns the result of an HTTP REST request to the ESV scriptures
-// API. The ESV API supports returning multiple verses or even multiple passages
-// in one lookup request.
-//
-// The API is pretty flexible and does its best to decipher what you are looking
-// for.
-//
-// Example verseRef values that will work:
-//    romans 12:1
-//    2Tim1:13
-//    Psalm 3:3 Isaiah 53:5
-//    ps 119:9, 11
-//    1 Thess 5:16-18
-func lookupVerse(verseRef string, lineLength int, includeHeadings, includeFootnotes, indentPoetry, includeVerseNumbers bool) (*Passage, error) {
-	urlSafeVerseRef := strings.ReplaceAll(verseRef, " ", "+")
+// randomVerse returns a verse from anywhere in the given books.  Each verse
+// is equally likely, so long books and chapters are chosen more often than
+// short ones.
+func randomVerse(fromBooks ...Book) Reference {
+	n := 0
+	for _, book := range fromBooks {
+		for _, count := range book.Chapters() {
+			n += count
+		}
+	}
+	n = rand.Intn(n)
+	for _, book := range fromBooks {
+		for chapter, count := range book.Chapters() {
+			if n < count {
+				return Reference{book, chapter + 1, n + 1, chapter + 1, n + 1}
+			}
+			n -= count
+		}
+	}
+	panic("randomVerse: verse index out of range")
+}
 
-	url := fmt.Sprintf(`%s?q=%s&line-length=%d&include-headings=%t&include-footnotes=%t&indent-poetry=%t&include-verse-numbers=%t`,
-		baseApiURL,
-		urlSafeVerseRef,
-		lineLength,
+// Print out the passage from the reference given
+func displayPassage(passageRef string, includeHeadings, includeFootnotes, indentPoetry, includeVerseNumbers bool) (cleanPassageRef string, err error) {
+	passage, err := lookupVerse(passageRef, config.LineLength,
 		includeHeadings,
 		includeFootnotes,
 		indentPoetry,
 		includeVerseNumbers)
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading request.")
+	if _, ok := err.(*ReferenceError); ok {
+		displayErrorText(err.Error())
+		return "", err
+	} else if err != nil {
+		displayError("Error looking up verse", err)
+		return "", err
 	}
 
-	req.Header.Set("Authorization", "Token "+apiToken)
+	if len(passage.Passages) == 0 {
+		displayErrorText("Passage not found")
+		return "", errNotFound
+	}
 
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading response.")
+	cleanPassageRef = passage.VerseRef
+	for _, passageText := range passage.Passages {
+		//fmt.Println("============================================================")
+		fmt.Println(passageText)
 	}
-	defer resp.Body.Close()
 
-	jsonBody := Passage{}
-	err = json.NewDecoder(resp.Body).Decode(&jsonBody)
+	return cleanPassageRef, nil
+}
+
+// lookupVerse parses the reference and returns the passage text from the
+// current text provider.  Both providers support returning multiple verses in
+// one lookup request.  See ParseReferences for the references that will work.
+func lookupVerse(verseRef string, lineLength int, includeHeadings, includeFootnotes, indentPoetry, includeVerseNumbers bool) (*Passage, error) {
+	refs, err := ParseReferences(verseRef)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error reading response.")
+		return nil, err
 	}
-
-	return &jsonBody, nil
+	return textProvider.Passage(refs, PassageOptions{
+		LineLength:          lineLength,
+		IncludeHeadings:     includeHeadings,
+		IncludeFootnotes:    includeFootnotes,
+		IndentPoetry:        indentPoetry,
+		IncludeVerseNumbers: includeVerseNumbers,
+	})
 }
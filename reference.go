@@ -0,0 +1,318 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Parses verse references like these into Reference values:
+//
+//	romans 12:1
+//	2Tim1:13
+//	Psalm 3:3 Isaiah 53:5
+//	ps 119:9, 11
+//	1 Thess 5:16-18
+//	Rom 8-9
+//	Rom 8:38-9:2; 12:1
+//
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// referenceBookRegex finds each book name and the chapter and verse
+	// numbers that follow it.  i.e. "2 tim 1:7", "rom8.28-30", "psalm 23",
+	// "phil. 4:13" or "ps 119:9, 11"
+	referenceBookRegex = regexp.MustCompile(`(?:^|\s)([1-3]?\s*[a-z][a-z ]*?\.?)\s*(\d+(?:\s*[:.,-]\s*\d+)*)`)
+
+	leadingNumberRegex = regexp.MustCompile(`^([1-3])\s+`)
+
+	// dashReplacer replaces the en and em dashes in canonical references
+	// returned by the ESV API.  i.e. "Romans 8:38–9:2"
+	dashReplacer = strings.NewReplacer("–", "-", "—", "-")
+)
+
+// Reference is a range of verses in one book.  A start verse of zero is the
+// start of the chapter and an end verse of zero is the end of the chapter.
+type Reference struct {
+	Book         Book
+	StartChapter int
+	StartVerse   int
+	EndChapter   int
+	EndVerse     int
+}
+
+// String returns the full reference.  i.e. "Romans 8:38-9:2"
+func (r Reference) String() string {
+	name := r.Book.FullName
+	switch {
+	case r.StartVerse == 0 && r.StartChapter == r.EndChapter:
+		return fmt.Sprintf("%s %d", name, r.StartChapter)
+	case r.StartVerse == 0:
+		return fmt.Sprintf("%s %d-%d", name, r.StartChapter, r.EndChapter)
+	case r.StartChapter != r.EndChapter:
+		return fmt.Sprintf("%s %d:%d-%d:%d", name, r.StartChapter, r.StartVerse, r.EndChapter, r.EndVerse)
+	case r.StartVerse != r.EndVerse:
+		return fmt.Sprintf("%s %d:%d-%d", name, r.StartChapter, r.StartVerse, r.EndVerse)
+	default:
+		return fmt.Sprintf("%s %d:%d", name, r.StartChapter, r.StartVerse)
+	}
+}
+
+// IsSingleVerse returns true if the reference is exactly one verse
+func (r Reference) IsSingleVerse() bool {
+	return r.StartVerse > 0 && r.StartChapter == r.EndChapter && r.StartVerse == r.EndVerse
+}
+
+// Includes returns true if the chapter and verse are inside the reference
+func (r Reference) Includes(chapter, verse int) bool {
+	if chapter < r.StartChapter || chapter > r.EndChapter {
+		return false
+	}
+	if chapter == r.StartChapter && r.StartVerse > 0 && verse < r.StartVerse {
+		return false
+	}
+	if chapter == r.EndChapter && r.EndVerse > 0 && verse > r.EndVerse {
+		return false
+	}
+	return true
+}
+
+// Verses returns each verse of the reference as its own reference
+func (r Reference) Verses() []Reference {
+	var verses []Reference
+	for chapter := r.StartChapter; chapter <= r.EndChapter; chapter++ {
+		for verse := 1; verse <= r.Book.VerseCount(chapter); verse++ {
+			if r.Includes(chapter, verse) {
+				verses = append(verses, Reference{r.Book, chapter, verse, chapter, verse})
+			}
+		}
+	}
+	return verses
+}
+
+// TranslationRef returns the reference using the book names of the
+// translation map file.  i.e. "Rom 8:28"
+func (r Reference) TranslationRef() string {
+	return fmt.Sprintf("%s %d:%d", r.Book.TranslationName, r.StartChapter, r.StartVerse)
+}
+
+// ReferenceError explains why a reference could not be parsed.  Its cause is
+// errNotFound for an unknown book and errUsage for anything else.
+type ReferenceError struct {
+	Message string
+	cause   error
+}
+
+func (e *ReferenceError) Error() string {
+	return e.Message
+}
+
+// Cause lets errors.Cause find the sentinel error
+func (e *ReferenceError) Cause() error {
+	return e.cause
+}
+
+func referenceError(cause error, format string, a ...interface{}) error {
+	return &ReferenceError{Message: fmt.Sprintf(format, a...), cause: cause}
+}
+
+// FormatReferences returns the full references separated by semicolons
+func FormatReferences(refs []Reference) string {
+	strs := make([]string, len(refs))
+	for i, r := range refs {
+		strs[i] = r.String()
+	}
+	return strings.Join(strs, "; ")
+}
+
+// ParseReferences parses text with one or more passages.  Passages are
+// separated by a new book name or a semicolon.  Numbers after a semicolon
+// with no book name use the previous book.  Errors are a *ReferenceError.
+func ParseReferences(text string) ([]Reference, error) {
+	var refs []Reference
+	var book Book
+	text = dashReplacer.Replace(text)
+	for _, part := range strings.Split(strings.ToLower(text), ";") {
+		if len(strings.TrimSpace(part)) == 0 {
+			continue
+		}
+
+		matches := referenceBookRegex.FindAllStringSubmatchIndex(part, -1)
+		prefix := part
+		if matches != nil {
+			prefix = part[:matches[0][0]]
+		}
+		if prefix = strings.TrimSpace(prefix); len(prefix) > 0 {
+			if len(book.FullName) == 0 {
+				if _, ok := findBook(prefix); ok {
+					return nil, referenceError(errUsage, "Expected a chapter number after %s", prefix)
+				}
+				return nil, referenceError(errUsage, "Not a verse reference: %s", strings.TrimSpace(text))
+			}
+			bookRefs, err := parseReferenceNumbers(book, prefix)
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, bookRefs...)
+		}
+
+		for i, match := range matches {
+			// Anything between this match and the next is not understood
+			end := len(part)
+			if i+1 < len(matches) {
+				end = matches[i+1][0]
+			}
+			if extra := strings.TrimSpace(part[match[1]:end]); len(extra) > 0 {
+				return nil, referenceError(errUsage, "Unexpected %q in %s", extra, strings.TrimSpace(text))
+			}
+
+			var ok bool
+			name := part[match[2]:match[3]]
+			book, ok = findBook(name)
+			if !ok {
+				return nil, referenceError(errNotFound, "Unable to find book with name %s", strings.TrimSpace(name))
+			}
+			bookRefs, err := parseReferenceNumbers(book, part[match[4]:match[5]])
+			if err != nil {
+				return nil, err
+			}
+			refs = append(refs, bookRefs...)
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, referenceError(errUsage, "Not a verse reference: %s", strings.TrimSpace(text))
+	}
+	return refs, nil
+}
+
+// ParseReference parses text with exactly one passage
+func ParseReference(text string) (Reference, error) {
+	refs, err := ParseReferences(text)
+	if err != nil {
+		return Reference{}, err
+	}
+	if len(refs) > 1 {
+		return Reference{}, referenceError(errUsage, "Expected only one passage: %s", strings.TrimSpace(text))
+	}
+	return refs[0], nil
+}
+
+// parseReferenceNumbers parses the chapter and verse numbers that follow a
+// book name.  i.e. "8:28-30", "119:9, 11", "8-9", "8:38-9:2" or "Jude 3"
+func parseReferenceNumbers(book Book, numbers string) ([]Reference, error) {
+	numbers = strings.ReplaceAll(whitespaceRegex.ReplaceAllString(numbers, ""), ".", ":")
+
+	// The numbers of books with one chapter are verses.  i.e. "Jude 3"
+	if len(book.Chapters()) == 1 && !strings.Contains(numbers, ":") {
+		numbers = "1:" + numbers
+	}
+
+	var refs []Reference
+	chapter := 0
+	for _, item := range strings.Split(numbers, ",") {
+		r := Reference{Book: book}
+		start, end := item, ""
+		if ix := strings.Index(item, "-"); ix >= 0 {
+			start, end = item[:ix], item[ix+1:]
+		}
+		if len(start) == 0 || strings.Count(start, ":") > 1 || strings.Count(end, ":") > 1 ||
+			strings.HasSuffix(item, "-") || strings.HasSuffix(item, ":") {
+			return nil, referenceError(errUsage, "Unable to understand %q in %s %s", item, book.FullName, numbers)
+		}
+
+		// The start is "C:V", or "V" after a verse in the same chapter, or "C"
+		if ix := strings.Index(start, ":"); ix >= 0 {
+			chapter = leadingInt(start[:ix])
+			r.StartChapter, r.StartVerse = chapter, leadingInt(start[ix+1:])
+		} else if chapter > 0 {
+			r.StartChapter, r.StartVerse = chapter, leadingInt(start)
+		} else {
+			r.StartChapter = leadingInt(start)
+		}
+
+		// The end is "C:V", or "V" in the same chapter, or "C" after a chapter
+		switch {
+		case len(end) == 0:
+			r.EndChapter, r.EndVerse = r.StartChapter, r.StartVerse
+		case strings.Contains(end, ":"):
+			ix := strings.Index(end, ":")
+			r.EndChapter, r.EndVerse = leadingInt(end[:ix]), leadingInt(end[ix+1:])
+		case r.StartVerse > 0:
+			r.EndChapter, r.EndVerse = r.StartChapter, leadingInt(end)
+		default:
+			r.EndChapter = leadingInt(end)
+		}
+
+		switch {
+		case r.StartChapter == 0 || (strings.Contains(start, ":") && r.StartVerse == 0):
+			return nil, referenceError(errUsage, "Chapters and verses start at 1: %s %s", book.FullName, item)
+		case r.EndChapter < r.StartChapter ||
+			(r.EndChapter == r.StartChapter && r.EndVerse < r.StartVerse):
+			return nil, referenceError(errUsage, "The range ends before it starts: %s %s", book.FullName, item)
+		}
+		if err := checkVersification(r); err != nil {
+			return nil, err
+		}
+		// A verse after a range is in the chapter the range ends in.
+		// i.e. the 5 of "8:38-9:2, 5" is 9:5
+		if r.StartVerse > 0 {
+			chapter = r.EndChapter
+		}
+		refs = append(refs, r)
+	}
+	return refs, nil
+}
+
+// checkVersification returns an error if the chapters or verses of the
+// reference are not in the book
+func checkVersification(r Reference) error {
+	book := r.Book
+	numChapters := len(book.Chapters())
+	for _, cv := range [][2]int{{r.StartChapter, r.StartVerse}, {r.EndChapter, r.EndVerse}} {
+		chapter, verse := cv[0], cv[1]
+		if chapter > numChapters {
+			if numChapters == 1 {
+				return referenceError(errNotFound, "%s has only 1 chapter", book.FullName)
+			}
+			return referenceError(errNotFound, "%s has only %d chapters", book.FullName, numChapters)
+		}
+		if verse > book.VerseCount(chapter) {
+			return referenceError(errNotFound, "%s %d has only %d verses", book.FullName, chapter, book.VerseCount(chapter))
+		}
+	}
+	return nil
+}
+
+// findBook looks up a book by full name, alias or TranslationName, ignoring
+// case, a trailing period and the space after a leading number.  i.e.
+// "1 Thess", "1th" or "Phil."
+func findBook(name string) (Book, bool) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if book, ok := bookNameMap[name]; ok {
+		return book, true
+	}
+	book, ok := bookNameMap[leadingNumberRegex.ReplaceAllString(name, "$1")]
+	return book, ok
+}
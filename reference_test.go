@@ -0,0 +1,72 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import "testing"
+
+func TestParseReferenceAbbreviations(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		chapter int
+		verse   int
+	}{
+		{"Phil 4:13", "Philippians", 4, 13},
+		{"Phil. 4:13", "Philippians", 4, 13},
+		{"Jn 3:16", "John", 3, 16},
+		{"Mt 5:3", "Matthew", 5, 3},
+		{"Mk 1:1", "Mark", 1, 1},
+		{"1 Chr 4:10", "1 Chronicles", 4, 10},
+		{"1Chr. 4:10", "1 Chronicles", 4, 10},
+		{"Dt 6:4", "Deuteronomy", 6, 4},
+		{"Lk 2:1", "Luke", 2, 1},
+		{"1 John 4:8", "1 John", 4, 8},
+		{"1john 4:8", "1 John", 4, 8},
+		{"rom8.28", "Romans", 8, 28},
+	}
+	for _, test := range tests {
+		ref, err := ParseReference(test.text)
+		if err != nil {
+			t.Errorf("ParseReference(%q) returned error: %v", test.text, err)
+			continue
+		}
+		if ref.Book.FullName != test.want || ref.StartChapter != test.chapter || ref.StartVerse != test.verse {
+			t.Errorf("ParseReference(%q) = %s, want %s %d:%d", test.text, ref, test.want, test.chapter, test.verse)
+		}
+	}
+}
+
+func TestParseReferencesAfterChapterRange(t *testing.T) {
+	refs, err := ParseReferences("Rom 8:38-9:2, 5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 {
+		t.Fatalf("ParseReferences returned %d references, want 2", len(refs))
+	}
+	if r := refs[0]; r.StartChapter != 8 || r.StartVerse != 38 || r.EndChapter != 9 || r.EndVerse != 2 {
+		t.Errorf("first reference is %s, want Romans 8:38-9:2", r)
+	}
+	if r := refs[1]; r.StartChapter != 9 || r.StartVerse != 5 || r.EndChapter != 9 || r.EndVerse != 5 {
+		t.Errorf("second reference is %s, want Romans 9:5", r)
+	}
+}
@@ -0,0 +1,406 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Spaced repetition review of declarations and memory verses using the SM-2
+// algorithm: https://www.supermemo.com/en/archives1990-2015/english/ol/sm2
+//
+// Each review is graded from 0 (no recall) to 5 (perfect recall).  Items
+// recalled well are shown again after a growing number of days, while items
+// that are forgotten start over the next day.  The schedule is kept in the
+// data directory.
+//
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	reviewFileName     = "review.json"
+	initialEasiness    = 2.5
+	minimumEasiness    = 1.3
+	declarationKeyType = "declaration"
+	verseKeyType       = "verse"
+)
+
+// ReviewSchedule is the review state of every declaration and memory verse
+type ReviewSchedule struct {
+	// Items are keyed by type and id.  i.e. "declaration:3" or "verse:Romans 8:28"
+	Items map[string]*ReviewItem `json:"items"`
+
+	// MemoryVerses are the references of the verses being memorized
+	MemoryVerses []string `json:"memoryVerses"`
+
+	// LastDeclarationID is the highest declaration ID handed out, so that a
+	// new declaration never gets the ID (and review state) of a deleted one
+	LastDeclarationID int `json:"lastDeclarationID,omitempty"`
+}
+
+// ReviewItem is the SM-2 state of one declaration or memory verse
+type ReviewItem struct {
+	Easiness     float64   `json:"easiness"`
+	Interval     int       `json:"interval"` // in days
+	Repetitions  int       `json:"repetitions"`
+	Due          time.Time `json:"due"`
+	LastReviewed time.Time `json:"lastReviewed"`
+}
+
+// reviewCard is one thing to recall in a review session
+type reviewCard struct {
+	key      string
+	prompt   string // what is shown before recalling
+	answer   func() // shows what should have been recalled
+	reviewed func() // called after grading
+}
+
+// grade updates the item with the quality of recall (0-5) using SM-2
+func (item *ReviewItem) grade(quality int, now time.Time) {
+	if quality >= 3 {
+		switch item.Repetitions {
+		case 0:
+			item.Interval = 1
+		case 1:
+			item.Interval = 6
+		default:
+			item.Interval = int(math.Round(float64(item.Interval) * item.Easiness))
+		}
+		item.Repetitions++
+	} else {
+		item.Repetitions = 0
+		item.Interval = 1
+	}
+
+	q := float64(5 - quality)
+	item.Easiness += 0.1 - q*(0.08+q*0.02)
+	if item.Easiness < minimumEasiness {
+		item.Easiness = minimumEasiness
+	}
+
+	item.LastReviewed = now
+	year, month, day := now.Date()
+	item.Due = time.Date(year, month, day+item.Interval, 0, 0, 0, 0, now.Location())
+}
+
+// runReview reviews the declarations and memory verses that are due.  The
+// argument may limit the review to "declarations" or "verses".
+func runReview(args string) error {
+	args = strings.ToLower(strings.TrimSpace(args))
+	includeDeclarations := len(args) == 0 || strings.HasPrefix(args, "d")
+	includeVerses := len(args) == 0 || strings.HasPrefix(args, "v")
+	if !includeDeclarations && !includeVerses {
+		displayErrorText("Expected review, review declarations or review verses")
+		return errUsage
+	}
+
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		return err
+	}
+
+	var cards []reviewCard
+	var declarations []Declaration
+	if includeDeclarations {
+		declarations, err = loadDeclarations()
+		if err != nil {
+			return err
+		}
+		for i := range declarations {
+			cards = append(cards, declarationCard(declarations, i))
+		}
+	}
+	if includeVerses {
+		for _, ref := range schedule.MemoryVerses {
+			cards = append(cards, verseCard(ref))
+		}
+	}
+
+	now := time.Now()
+	cards = dueCards(schedule, cards, now)
+	if len(cards) == 0 {
+		fmt.Println("Nothing is due for review.  Well done!")
+		printNextReview(schedule)
+		return nil
+	}
+
+	fmt.Printf("%d to review.  Grade each from 0 (forgot) to 5 (perfect), or q to stop.\n\n", len(cards))
+	reviewed := 0
+	for _, card := range cards {
+		theme.Heading.Println(card.prompt)
+		theme.Hint.Print("Press enter to show it ")
+		if _, err := reader.ReadString('\n'); err != nil {
+			return err
+		}
+		card.answer()
+
+		quality, ok := readQuality()
+		if !ok {
+			break
+		}
+		item := schedule.item(card.key)
+		item.grade(quality, now)
+		card.reviewed()
+		if err := saveReviewSchedule(schedule); err != nil {
+			return err
+		}
+		reviewed++
+		theme.Muted.Printf("Next review in %d day(s)\n\n", item.Interval)
+	}
+
+	if includeDeclarations && reviewed > 0 {
+		if err := saveDeclarations(declarations); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Reviewed %d of %d\n", reviewed, len(cards))
+	printNextReview(schedule)
+	return nil
+}
+
+// declarationCard asks to recall the text of a declaration from its reference
+func declarationCard(declarations []Declaration, i int) reviewCard {
+	d := &declarations[i]
+	prompt := "Declaration for " + d.Reference
+	if len(d.Tags) > 0 {
+		prompt += "  #" + strings.Join(d.Tags, " #")
+	}
+	return reviewCard{
+		key:    declarationKey(d.ID),
+		prompt: prompt,
+		answer: func() { printDeclaration(*d) },
+		reviewed: func() {
+			now := time.Now()
+			d.LastReviewed = &now
+		},
+	}
+}
+
+// verseCard asks to recall the text of a memory verse from its reference
+func verseCard(ref string) reviewCard {
+	return reviewCard{
+		key:    verseKeyType + ":" + ref,
+		prompt: "Memory verse " + ref,
+		answer: func() {
+			displayPassage(ref,
+				false, /*includeHeadings*/
+				false, /*includeFootnotes*/
+				true,  /*indentPoetry*/
+				true /*includeVerseNumbers*/)
+		},
+		reviewed: func() {},
+	}
+}
+
+// dueCards returns the cards that are due, or have never been reviewed,
+// with the most overdue first
+func dueCards(schedule *ReviewSchedule, cards []reviewCard, now time.Time) []reviewCard {
+	var due []reviewCard
+	for _, card := range cards {
+		if item, ok := schedule.Items[card.key]; !ok || !item.Due.After(now) {
+			due = append(due, card)
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return schedule.dueDate(due[i].key).Before(schedule.dueDate(due[j].key))
+	})
+	return due
+}
+
+// readQuality reads a grade from 0 to 5.  ok is false if the user wants to stop.
+func readQuality() (quality int, ok bool) {
+	for {
+		theme.Prompt.Print("Grade 0-5 > ")
+		text, err := reader.ReadString('\n')
+		if err != nil {
+			return 0, false
+		}
+		text = strings.ToLower(strings.TrimSpace(text))
+		if text == "q" || text == "quit" {
+			return 0, false
+		}
+		quality, err := strconv.Atoi(text)
+		if err == nil && quality >= 0 && quality <= 5 {
+			return quality, true
+		}
+		displayErrorText("Enter a number from 0 to 5, or q to stop")
+	}
+}
+
+// printNextReview shows when the next item is due
+func printNextReview(schedule *ReviewSchedule) {
+	var next time.Time
+	for _, item := range schedule.Items {
+		if next.IsZero() || item.Due.Before(next) {
+			next = item.Due
+		}
+	}
+	if !next.IsZero() {
+		theme.Muted.Printf("Next review is due %s\n", next.Format("Mon Jan 2, 2006"))
+	}
+}
+
+// runMemorize manages the memory verses:
+//
+//	(nothing)  - adds the current verse
+//	list       - lists the memory verses with their numbers
+//	delete 3   - deletes memory verse 3
+func runMemorize(args string) error {
+	schedule, err := loadReviewSchedule()
+	if err != nil {
+		return err
+	}
+
+	words := strings.Fields(strings.ToLower(args))
+	switch {
+	case len(words) == 0:
+		if len(previousPassageRef) == 0 {
+			displayErrorText("You have not looked up a verse to memorize.")
+			return errUsage
+		}
+		for _, ref := range schedule.MemoryVerses {
+			if ref == previousPassageRef {
+				fmt.Printf("%s is already a memory verse\n", ref)
+				return nil
+			}
+		}
+		schedule.MemoryVerses = append(schedule.MemoryVerses, previousPassageRef)
+		if err := saveReviewSchedule(schedule); err != nil {
+			return err
+		}
+		fmt.Printf("Added memory verse %s.  It will be part of your next review.\n", previousPassageRef)
+
+	case words[0] == "list":
+		if len(schedule.MemoryVerses) == 0 {
+			displayErrorText("There are no memory verses.  Look up a verse, then enter mem to add it.")
+			return errNotFound
+		}
+		for i, ref := range schedule.MemoryVerses {
+			theme.Muted.Printf("%3d. ", i+1)
+			fmt.Print(ref)
+			due := schedule.dueDate(verseKeyType + ":" + ref)
+			if due.After(time.Now()) {
+				theme.Muted.Printf("  due %s\n", due.Format("Jan 2"))
+			} else {
+				theme.Muted.Println("  due now")
+			}
+		}
+
+	case words[0] == "delete" && len(words) == 2:
+		n, err := strconv.Atoi(words[1])
+		if err != nil || n < 1 || n > len(schedule.MemoryVerses) {
+			displayErrorText(fmt.Sprintf("There is no memory verse %s", words[1]))
+			return errUsage
+		}
+		ref := schedule.MemoryVerses[n-1]
+		schedule.MemoryVerses = append(schedule.MemoryVerses[:n-1], schedule.MemoryVerses[n:]...)
+		delete(schedule.Items, verseKeyType+":"+ref)
+		if err := saveReviewSchedule(schedule); err != nil {
+			return err
+		}
+		fmt.Printf("Deleted memory verse %d: %s\n", n, ref)
+
+	default:
+		displayErrorText("Expected mem, mem list or mem delete <n>")
+		return errUsage
+	}
+	return nil
+}
+
+// item returns the review item for the key, adding a new one if needed
+func (schedule *ReviewSchedule) item(key string) *ReviewItem {
+	item, ok := schedule.Items[key]
+	if !ok {
+		item = &ReviewItem{Easiness: initialEasiness}
+		schedule.Items[key] = item
+	}
+	return item
+}
+
+// declarationKey returns the key of a declaration's item.  i.e. "declaration:3"
+func declarationKey(id int) string {
+	return declarationKeyType + ":" + strconv.Itoa(id)
+}
+
+// nextDeclarationID returns an ID that no declaration has had: one more than
+// the highest ID in use or ever handed out
+func (schedule *ReviewSchedule) nextDeclarationID(declarations []Declaration) int {
+	id := schedule.LastDeclarationID
+	for _, d := range declarations {
+		if d.ID > id {
+			id = d.ID
+		}
+	}
+	return id + 1
+}
+
+// dueDate returns when the item is due.  Items never reviewed are due now.
+func (schedule *ReviewSchedule) dueDate(key string) time.Time {
+	if item, ok := schedule.Items[key]; ok {
+		return item.Due
+	}
+	return time.Time{}
+}
+
+// loadReviewSchedule reads the schedule from the data directory.  A missing
+// file is an empty schedule.
+func loadReviewSchedule() (*ReviewSchedule, error) {
+	schedule := &ReviewSchedule{Items: map[string]*ReviewItem{}}
+	data, err := os.ReadFile(filepath.Join(dataDirPath, reviewFileName))
+	if os.IsNotExist(err) {
+		return schedule, nil
+	} else if err != nil {
+		displayError("Error reading review schedule", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, schedule); err != nil {
+		err = errors.Wrap(err, "Error reading review schedule")
+		displayError("Error", err)
+		return nil, err
+	}
+	if schedule.Items == nil {
+		schedule.Items = map[string]*ReviewItem{}
+	}
+	return schedule, nil
+}
+
+// saveReviewSchedule writes the schedule to the data directory atomically
+func saveReviewSchedule(schedule *ReviewSchedule) error {
+	data, err := json.MarshalIndent(schedule, "", "  ")
+	if err == nil {
+		err = WriteLinesAtomic(filepath.Join(dataDirPath, reviewFileName), []string{string(data)})
+	}
+	if err != nil {
+		displayError("Error saving review schedule", err)
+	}
+	return err
+}
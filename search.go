@@ -22,80 +22,201 @@ THE SOFTWARE.
 package main
 
 //
-// Handles ESV text search
+// Handles text search
 //
 
 import (
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"sort"
 	"strings"
-	"time"
-
-	"github.com/pkg/errors"
-)
-
-const (
-	baseSearchUrl = "https://api.esv.org/v3/passage/search"
 )
 
 // SearchResults holds the results from one search
 type SearchResults struct {
-	Results []Result `json:"results"`
+	Results      []Result `json:"results"`
+	TotalResults int      `json:"total_results"`
+	TotalPages   int      `json:"total_pages"`
+
+	// more returns the next page of results.  It is nil once every result
+	// that can be returned is in Results.
+	more func() ([]Result, error)
+}
+
+// fetchMore adds the next page of results.  It returns false if there are
+// no more.
+func (r *SearchResults) fetchMore() (bool, error) {
+	if r.more == nil {
+		return false, nil
+	}
+	results, err := r.more()
+	if err != nil {
+		return false, err
+	}
+	r.Results = append(r.Results, results...)
+	return true, nil
+}
+
+// fetchAll adds every page of results
+func (r *SearchResults) fetchAll() error {
+	for {
+		if more, err := r.fetchMore(); err != nil || !more {
+			return err
+		}
+	}
 }
 
 // Result represents a verse that matches the search text
 type Result struct {
 	Reference string `json:"reference"`
 	Content   string `json:"content"`
+
+	// Highlights are the start and end byte offsets of the matching words
+	// in Content, if the provider knows them
+	Highlights [][2]int `json:"-"`
 }
 
-// displaySearchResults shows results of searching for a given word or words
+// displaySearchResults shows results of searching for a given word or words.
+// The search may end with "in" and the books, categories or testaments to
+// search (i.e. "fear in nt" or "peace in psalms proverbs"), and may include
+// -sort to show the results in the order of the books.
 func displaySearchResults(searchString string) error {
-	results, err := searchESV(searchString)
-	if err != nil {
+	words, sorted := searchOptions(strings.Fields(searchString))
+	words, filterWords := splitSearchFilter(words)
+	if len(words) == 0 {
+		displayErrorText("Enter one or more words to search for")
+		return errUsage
+	}
+
+	results, err := textProvider.Search(strings.Join(words, " "))
+	if _, ok := err.(*QueryError); ok {
+		displayErrorText(err.Error())
+		return err
+	} else if err != nil {
+		displayError("Error searching", err)
 		return err
 	}
 
-	if len(results.Results) == 0 {
-		displayErrorText("No results found")
-	} else {
-		for _, result := range results.Results {
-			fmt.Printf("%s - %s\n\n", result.Reference, result.Content)
+	var bookNames map[string]bool
+	if len(filterWords) > 0 {
+		bookNames = map[string]bool{}
+		for _, name := range *findBooksMatchingWords(filterWords) {
+			bookNames[name] = true
 		}
 	}
 
-	return nil
-}
+	// Results are filtered as they are fetched, and only as many pages are
+	// fetched as are needed to show the page asked for.  Sorting needs them all.
+	var found []Result
+	filtered := 0
+	load := func(need int) (int, bool, error) {
+		for {
+			for _, result := range results.Results[filtered:] {
+				if ref, err := ParseReference(result.Reference); bookNames == nil || (err == nil && bookNames[ref.Book.TranslationName]) {
+					found = append(found, result)
+				}
+			}
+			filtered = len(results.Results)
+			if len(found) >= need && !sorted {
+				return len(found), results.more == nil, nil
+			}
+			if more, err := results.fetchMore(); err != nil {
+				displayError("Error searching", err)
+				return 0, false, err
+			} else if !more {
+				if sorted {
+					sortResults(found)
+				}
+				if results.TotalResults > len(results.Results) {
+					theme.Muted.Printf("Only the first %d of %d results were returned.  Add words to find fewer.\n", len(results.Results), results.TotalResults)
+				}
+				return len(found), true, nil
+			}
+		}
+	}
 
-// searchESV sends the searchString to the API and displays the results.
-// This is not ideal given that you cannot choose which testament to search in.
-// Search results are currently capped at 100 so if you search on "fear" you
-// will never receive any NT results.
-func searchESV(searchString string) (*SearchResults, error) {
-	urlSafeSearchString := strings.ReplaceAll(searchString, " ", "+")
+	if _, _, err := load(pageSize + 1); err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		displayErrorText("No results found")
+		return errNotFound
+	}
 
-	url := fmt.Sprintf(`%s?q=%s&page-size=100&page=1`, baseSearchUrl, urlSafeSearchString)
+	if results.more == nil {
+		fmt.Printf("Found %d verses\n", len(found))
+	}
+	return runLoadingPager(load, func(start, end int) error {
+		for _, result := range found[start:end] {
+			fmt.Printf("%s - %s\n\n", result.Reference, highlight(result.Content, result.Highlights))
+		}
+		return nil
+	})
+}
 
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading request.")
+// highlight colors the given byte ranges of the text
+func highlight(text string, ranges [][2]int) string {
+	if len(ranges) == 0 {
+		return text
 	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
 
-	req.Header.Set("Authorization", "Token "+apiToken)
+	var sb strings.Builder
+	end := 0
+	for _, r := range ranges {
+		if r[0] < end {
+			continue // the same word matched twice
+		}
+		sb.WriteString(text[end:r[0]])
+		sb.WriteString(theme.Highlight.Sprint(text[r[0]:r[1]]))
+		end = r[1]
+	}
+	sb.WriteString(text[end:])
+	return sb.String()
+}
 
-	client := &http.Client{Timeout: time.Second * 10}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading response.")
+// searchOptions removes the options from the words of the search
+func searchOptions(words []string) (remaining []string, sorted bool) {
+	for _, word := range words {
+		if word == "-sort" || word == "--sort" {
+			sorted = true
+		} else {
+			remaining = append(remaining, word)
+		}
 	}
-	defer resp.Body.Close()
+	return remaining, sorted
+}
 
-	jsonBody := SearchResults{}
-	err = json.NewDecoder(resp.Body).Decode(&jsonBody)
-	if err != nil {
-		return nil, errors.Wrap(err, "Error reading response.")
+// splitSearchFilter splits "fear in nt gospels" into the words to search
+// for and the filter words.  The words after the last "in" are only a filter
+// if every one is a book, category or testament, so "abide in me" is a
+// search for all three words.
+func splitSearchFilter(words []string) (search []string, filter []string) {
+	for i := len(words) - 2; i > 0; i-- {
+		if strings.ToLower(words[i]) != "in" {
+			continue
+		}
+		filter = strings.Fields(strings.ToLower(strings.Join(words[i+1:], " ")))
+		for _, word := range filter {
+			if _, ok := filters[word]; !ok {
+				return words, nil
+			}
+		}
+		return words[:i], filter
 	}
+	return words, nil
+}
 
-	return &jsonBody, nil
+// sortResults puts the results in the order of the books, chapters and
+// verses.  Results with references that cannot be parsed go last.
+func sortResults(results []Result) {
+	keys := map[string]int{}
+	for _, result := range results {
+		keys[result.Reference] = 1 << 30
+		if ref, err := ParseReference(result.Reference); err == nil {
+			keys[result.Reference] = ref.Book.Number()*1000000 + ref.StartChapter*1000 + ref.StartVerse
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return keys[results[i].Reference] < keys[results[j].Reference]
+	})
 }
@@ -0,0 +1,184 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Finds the strongs numbers that the ESV translates with an English word.
+// The verses using the word are found in the local text, or with a search of
+// the ESV, then the translation map line of each verse tells which strongs
+// numbers are at the positions of the word.
+//
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// strongsCount is the number of times a strongs number is translated with
+// the English word
+type strongsCount struct {
+	Strongs string
+	Count   int
+}
+
+// strongsFor lists the strongs numbers translated with the English word or
+// phrase, most used first
+func strongsFor(english string) error {
+	english = strings.ToLower(strings.Trim(strings.TrimSpace(english), `"`))
+	if len(english) == 0 {
+		displayErrorText("Enter an English word, i.e. strongsfor love")
+		return errUsage
+	}
+	if textProvider.Name() != "ESV" {
+		theme.Muted.Printf("The strongs numbers are mapped to ESV words, so %s words may not line up.\n", textProvider.Name())
+	}
+
+	queryWords := strings.Fields(english)
+	results, err := versesUsingWords(queryWords)
+	if err != nil {
+		displayError("Error searching", err)
+		return err
+	}
+
+	counts := map[string]int{}
+	numVerses := 0
+	for _, result := range results {
+		ref, err := ParseReference(result.Reference)
+		if err != nil {
+			continue
+		}
+		mapLine, err := translationMapLine(ref.TranslationRef())
+		if err != nil {
+			continue
+		}
+
+		positions := englishPositions(verseWords(result.Content), queryWords)
+		found := false
+		for _, mapping := range parseWordMappings(ref.TranslationRef(), mapLine) {
+			for _, position := range mapping.Positions {
+				if positions[position] {
+					found = true
+					for _, strongs := range mapping.Strongs {
+						counts[strongs]++
+					}
+					break
+				}
+			}
+		}
+		if found {
+			numVerses++
+		}
+	}
+
+	if len(counts) == 0 {
+		displayErrorText("No strongs numbers found for " + english)
+		return errNotFound
+	}
+	printStrongsCounts(english, counts, numVerses)
+	return nil
+}
+
+// versesUsingWords returns the verses that may use the words.  Every verse
+// of a local text is looked at.  The ESV is searched, and the API returns
+// only some of the verses of a common word.
+func versesUsingWords(queryWords []string) ([]Result, error) {
+	if local, ok := textProvider.(*localProvider); ok {
+		if err := local.load(); err != nil {
+			return nil, err
+		}
+		var results []Result
+		for _, v := range local.verses {
+			if len(englishPositions(verseWords(v.Text), queryWords)) > 0 {
+				results = append(results, Result{Reference: v.Reference(), Content: v.Text})
+			}
+		}
+		return results, nil
+	}
+
+	query := strings.Join(queryWords, " ")
+	if len(queryWords) > 1 {
+		query = `"` + query + `"`
+	}
+	results, err := textProvider.Search(query)
+	if err == nil {
+		err = results.fetchAll()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if results.TotalResults > len(results.Results) {
+		theme.Muted.Printf("Only the first %d of %d verses using %s were returned, so the counts are incomplete.\n",
+			len(results.Results), results.TotalResults, query)
+	}
+	return results.Results, nil
+}
+
+// englishPositions returns the positions (from 1) of the words of every
+// place the query words are used.  Each word matches other forms with the
+// same stem, so "love" also matches "loved" and "loveth".
+func englishPositions(words []string, queryWords []string) map[int]bool {
+	positions := map[int]bool{}
+	for start := 0; start+len(queryWords) <= len(words); start++ {
+		found := true
+		for i, queryWord := range queryWords {
+			if stem(strings.ToLower(cleanWord(words[start+i]))) != stem(queryWord) {
+				found = false
+				break
+			}
+		}
+		if found {
+			for i := range queryWords {
+				positions[start+i+1] = true
+			}
+		}
+	}
+	return positions
+}
+
+// printStrongsCounts prints each strongs number with its count and a gloss
+// from the lexicon
+func printStrongsCounts(english string, counts map[string]int, numVerses int) {
+	var sorted []strongsCount
+	for strongs, count := range counts {
+		sorted = append(sorted, strongsCount{strongs, count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Strongs < sorted[j].Strongs
+	})
+
+	fmt.Printf("%q translates %d strongs numbers in %d verses\n", english, len(sorted), numVerses)
+	for _, sc := range sorted {
+		lemma, gloss := "", ""
+		if entry, err := lookupStrongs(sc.Strongs); err == nil {
+			lemma, gloss = entry.Lemma, shortGloss(entry)
+		}
+		theme.Heading.Printf("  %-6s", sc.Strongs)
+		fmt.Printf(" %5s  %-14s ", strconv.Itoa(sc.Count), lemma)
+		theme.Muted.Println(gloss)
+	}
+	theme.Hint.Printf("Enter %s search to list its verses, or %s for its definition\n", sorted[0].Strongs, sorted[0].Strongs)
+}
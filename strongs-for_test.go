@@ -0,0 +1,111 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cappedProvider returns fewer search results than it says there are
+type cappedProvider struct {
+	results []Result
+	total   int
+}
+
+func (p *cappedProvider) Name() string { return "ESV" }
+
+func (p *cappedProvider) Passage(refs []Reference, options PassageOptions) (*Passage, error) {
+	return &Passage{}, nil
+}
+
+func (p *cappedProvider) Search(searchString string) (*SearchResults, error) {
+	return &SearchResults{Results: p.results, TotalResults: p.total}, nil
+}
+
+// useStrongsForData writes a translation map file and Strongs Greek file
+// for Romans 8:28-29
+func useStrongsForData(t *testing.T) string {
+	dir := useDataDir(t)
+	savedIndex := translationIdx
+	translationIdx = nil
+	t.Cleanup(func() { translationIdx = savedIndex })
+
+	if err := WriteLinesAtomic(translationMapFile, []string{
+		"$Rom 8:28\t01=<2532> 03=<1492> 05=<3956> 08=<25>",
+		"$Rom 8:29\t01=<3754> 04=<4267>",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(strongsGreekFile, []byte(testStrongsGreek), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestStrongsForLocalText(t *testing.T) {
+	dir := useStrongsForData(t)
+	savedProvider := textProvider
+	textProvider = newLocalProvider(filepath.Join(dir, "bible-text.txt"))
+	defer func() { textProvider = savedProvider }()
+	if err := WriteLinesAtomic(filepath.Join(dir, "bible-text.txt"), []string{
+		"# ESV",
+		"Rom 8:28\tAnd we know that for those who love God",
+		"Rom 8:29\tFor those whom he foreknew",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// "AND" would be an operator of a local search
+	out := captureOutput(t, func() {
+		if err := strongsFor("AND"); err != nil {
+			t.Error(err)
+		}
+	})
+	if !strings.Contains(out, `"and" translates 1 strongs numbers in 1 verses`) || !strings.Contains(out, "g2532") {
+		t.Errorf("strongsFor printed %q", out)
+	}
+}
+
+func TestStrongsForCappedSearch(t *testing.T) {
+	useStrongsForData(t)
+	savedProvider := textProvider
+	textProvider = &cappedProvider{
+		results: []Result{{Reference: "Romans 8:28", Content: "And we know that for those who love God"}},
+		total:   200,
+	}
+	defer func() { textProvider = savedProvider }()
+
+	out := captureOutput(t, func() {
+		if err := strongsFor("love"); err != nil {
+			t.Error(err)
+		}
+	})
+	if !strings.Contains(out, "Only the first 1 of 200 verses using love were returned") {
+		t.Errorf("strongsFor did not warn that the results were capped: %q", out)
+	}
+	if !strings.Contains(out, "g25") {
+		t.Errorf("strongsFor printed %q", out)
+	}
+}
@@ -21,86 +21,341 @@ THE SOFTWARE.
 */
 package main
 
+//
+// Parses the Strongs Greek and Hebrew definitions files into typed entries.
+// Each entry in the files looks like this:
+//
+//   $$T0000003
+//   \00003\
+//    3  Abaddon  ab-ad-dohn'
+//
+//    of Hebrew origin (11); a destroying angel:--Abaddon.
+//    see HEBREW for 011
+//
+
 import (
 	"bufio"
 	"fmt"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 
+	wordwrap "github.com/mitchellh/go-wordwrap"
 	"github.com/pkg/errors"
 )
 
-func displayStrongs(text string, file string) {
-	// Remove all non-digits (which should be the first character or nothing)
-	text = nonNumericRegexp.ReplaceAllString(text, "")
+const (
+	strongsLineWidth = 78
+	glossLength      = 40
+)
+
+var (
+	// previousStrongs is the latest strongs number displayed, so that its
+	// related entries can be followed.  i.e. "g4982"
+	previousStrongs = ""
+
+	// lexicons holds the parsed entries of each definitions file, keyed by
+	// file name and then strongs number
+	lexicons = map[string]map[int]*StrongsEntry{}
+
+	strongsHeaderRegex = regexp.MustCompile(`^\s*(\d+)\s+(\S.*?)(?:\s{2,}(\S.*))?$`)
+	strongsSeeRegex    = regexp.MustCompile(`^\s*see (GREEK|HEBREW) for (\d+)`)
+	wordNumberRegex    = regexp.MustCompile(`\b\d+\b`)
+)
+
+// StrongsEntry is one entry in the Strongs Greek or Hebrew lexicon
+type StrongsEntry struct {
+	Number int
+	Hebrew bool
+
+	// The definitions files only have the romanized form of each word, so
+	// the Lemma is the transliteration.  i.e. "sozo"
+	Lemma         string
+	Pronunciation string // i.e. "sode'-zo"
+
+	Derivation string // i.e. "of Hebrew origin (11)"
+	Definition string // i.e. "a destroying angel"
+	KJVUsage   string // i.e. "Abaddon"
+	Notes      string // anything after the KJV usage
+
+	// CrossRefs are the strongs numbers of related entries.  i.e. "h11"
+	CrossRefs []string
+}
+
+// Key returns the strongs number with its language prefix.  i.e. "g4982"
+func (e *StrongsEntry) Key() string {
+	if e.Hebrew {
+		return "h" + strconv.Itoa(e.Number)
+	}
+	return "g" + strconv.Itoa(e.Number)
+}
+
+// displayStrongs prints the definition of the given strongs number
+// (i.e. g4982 or h3068)
+func displayStrongs(strongsNum string) error {
+	entry, err := lookupStrongs(strongsNum)
+	if errors.Cause(err) == errNotFound {
+		displayErrorText("Definition not found")
+		return err
+	} else if err != nil {
+		displayError("Error reading "+strongsFileFor(strongsNum), err)
+		return err
+	}
+
+	previousStrongs = entry.Key()
+	printStrongsEntry(entry)
+	printRelatedStrongs(entry)
+	fmt.Println()
+	return nil
+}
 
-	// Grep the appropriate lines from the file
-	c, err := chooseLines(file, text)
+// displayRelatedStrongs lists the entries related to the given strongs
+// number with a short gloss for each
+func displayRelatedStrongs(strongsNum string) error {
+	entry, err := lookupStrongs(strongsNum)
 	if err != nil {
-		displayError("Error reading lines from "+file, err)
-		os.Exit(1)
+		displayError("Error looking up "+strongsNum, err)
+		return err
+	}
+	if len(entry.CrossRefs) == 0 {
+		displayErrorText("No related entries for " + entry.Key())
+		return errNotFound
 	}
 
-	// Print the lines
-	found := false
-	for line := range c {
-		found = true
-		fmt.Println(line)
+	previousStrongs = entry.Key()
+	printRelatedStrongs(entry)
+	fmt.Println()
+	return nil
+}
+
+// followRelatedStrongs displays the nth (starting at 1) related entry of the
+// previous strongs number
+func followRelatedStrongs(n int) error {
+	if len(previousStrongs) == 0 {
+		displayErrorText("You have not looked up a strongs number.")
+		return errUsage
 	}
-	if found {
-		fmt.Println()
-	} else {
-		displayErrorText("Definition not found")
+	entry, err := lookupStrongs(previousStrongs)
+	if err != nil {
+		displayError("Error looking up "+previousStrongs, err)
+		return err
+	}
+	if n < 1 || n > len(entry.CrossRefs) {
+		displayErrorText(fmt.Sprintf("%s has %d related entries", entry.Key(), len(entry.CrossRefs)))
+		return errUsage
 	}
+	return displayStrongs(entry.CrossRefs[n-1])
 }
 
-// chooseLines returns only the lines that apply to the given strongs number.
-//
-// Example Lines:
-//
-// $$T0000003
-// \00003\
-//  3  Abaddon  ab-ad-dohn'
-//
-//  of Hebrew origin (11); a destroying angel:--Abaddon.
-//  see HEBREW for 011
-// $$T0000004
-//
-func chooseLines(fileName string, strongsNum string) (<-chan string, error) {
-	c := make(chan string, 10)
-	strongsInt, err := strconv.Atoi(strongsNum)
+// printRelatedStrongs prints a numbered list of the cross references of the
+// entry with a short gloss for each
+func printRelatedStrongs(entry *StrongsEntry) {
+	if len(entry.CrossRefs) == 0 {
+		return
+	}
+	theme.Hint.Println("  Related:")
+	for i, ref := range entry.CrossRefs {
+		related, err := lookupStrongs(ref)
+		if err != nil {
+			fmt.Printf("  %3d) %s\n", i+1, ref)
+			continue
+		}
+		fmt.Printf("  %3d) %-6s %s", i+1, ref, related.Lemma)
+		theme.Muted.Printf(" - %s\n", shortGloss(related))
+	}
+}
+
+// shortGloss returns the KJV usage, or else the definition, shortened to
+// fit on one line with other text
+func shortGloss(entry *StrongsEntry) string {
+	gloss := entry.KJVUsage
+	if len(gloss) == 0 {
+		gloss = entry.Definition
+	}
+	if len(gloss) > glossLength {
+		gloss = gloss[:glossLength]
+		if ix := strings.LastIndexAny(gloss, " ,;"); ix > 0 {
+			gloss = gloss[:ix]
+		}
+		gloss += "..."
+	}
+	return gloss
+}
+
+// printStrongsEntry prints the entry with a colored heading and wrapped text
+func printStrongsEntry(entry *StrongsEntry) {
+	theme.Heading.Printf("%s  %s", strings.ToUpper(entry.Key()), entry.Lemma)
+	theme.Muted.Printf("  %s\n", entry.Pronunciation)
+
+	printStrongsField("", entry.Derivation)
+	printStrongsField("", entry.Definition)
+	printStrongsField("KJV: ", entry.KJVUsage)
+	printStrongsField("", entry.Notes)
+}
+
+// printStrongsField prints one wrapped and indented field of an entry
+func printStrongsField(label, text string) {
+	if len(text) == 0 {
+		return
+	}
+	wrapped := wordwrap.WrapString(label+text, strongsLineWidth)
+	for i, line := range strings.Split(wrapped, "\n") {
+		if i == 0 && len(label) > 0 {
+			theme.Hint.Print("  " + label)
+			fmt.Println(line[len(label):])
+		} else {
+			fmt.Println("  " + line)
+		}
+	}
+}
+
+// lookupStrongs returns the lexicon entry for the strongs number
+// (i.e. g4982 or h3068)
+func lookupStrongs(strongsNum string) (*StrongsEntry, error) {
+	strongsNum = strings.ToLower(strongsNum)
+	number, err := strconv.Atoi(nonNumericRegexp.ReplaceAllString(strongsNum, ""))
 	if err != nil {
-		return nil, errors.Wrap(err, "Invalid strongsNum argument to chooseLines")
+		return nil, errors.Wrap(errUsage, "Invalid strongs number "+strongsNum)
+	}
+
+	lexicon, err := loadLexicon(strongsFileFor(strongsNum))
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := lexicon[number]
+	if !ok {
+		return nil, errors.Wrap(errNotFound, "No definition for "+strongsNum)
+	}
+	return entry, nil
+}
+
+// strongsFileFor returns the Greek or Hebrew definitions file for the given
+// strongs number (i.e. g4982 or h3068)
+func strongsFileFor(strongsNum string) string {
+	if strings.HasPrefix(strings.ToLower(strongsNum), "h") {
+		return strongsHebrewFile
+	}
+	return strongsGreekFile
+}
+
+// loadLexicon parses the definitions file the first time it is needed
+func loadLexicon(fileName string) (map[int]*StrongsEntry, error) {
+	if lexicon, ok := lexicons[fileName]; ok {
+		return lexicon, nil
 	}
-	paddedStrongs := fmt.Sprintf("%07d", strongsInt)
 
-	startRegex := regexp.MustCompile(`^\$\$T` + paddedStrongs)
-	endRegex := regexp.MustCompile(`^\$\$T`)
 	file, err := os.Open(fileName)
 	if err != nil {
 		return nil, err
 	}
+	defer file.Close()
 
-	go func() {
-		defer file.Close()
-		scanner := bufio.NewScanner(file)
-		var start bool
-		for scanner.Scan() {
-			if start {
-				if endRegex.Match(scanner.Bytes()) {
-					// Do not include the endRegex line
-					break
-				}
-				c <- scanner.Text()
+	hebrew := fileName == strongsHebrewFile
+	lexicon := map[int]*StrongsEntry{}
+	var lines []string
+	addEntry := func() {
+		if entry := parseStrongsEntry(lines, hebrew); entry != nil {
+			lexicon[entry.Number] = entry
+		}
+		lines = lines[:0]
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "$$T") {
+			addEntry()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	addEntry()
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	lexicons[fileName] = lexicon
+	return lexicon, nil
+}
+
+// parseStrongsEntry parses the lines between two $$T lines.  nil is returned
+// if the lines are not an entry.
+func parseStrongsEntry(lines []string, hebrew bool) *StrongsEntry {
+	entry := &StrongsEntry{Hebrew: hebrew}
+	var body []string
+	var seeRefs []string
+	seeNumbers := map[int]bool{}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0:
+			continue
+		case strings.HasPrefix(trimmed, "\\") && strings.HasSuffix(trimmed, "\\"):
+			// The padded number.  i.e. \00003\
+			continue
+		case entry.Number == 0:
+			matches := strongsHeaderRegex.FindStringSubmatch(line)
+			if matches == nil {
+				return nil
 			}
-			if startRegex.Match(scanner.Bytes()) {
-				start = true
+			entry.Number, _ = strconv.Atoi(matches[1])
+			entry.Lemma = strings.TrimSpace(matches[2])
+			entry.Pronunciation = strings.TrimSpace(matches[3])
+		case strongsSeeRegex.MatchString(line):
+			matches := strongsSeeRegex.FindStringSubmatch(line)
+			number, _ := strconv.Atoi(matches[2])
+			prefix := "g"
+			if matches[1] == "HEBREW" {
+				prefix = "h"
 			}
+			seeRefs = append(seeRefs, prefix+strconv.Itoa(number))
+			seeNumbers[number] = true
+		default:
+			body = append(body, trimmed)
+		}
+	}
+	if entry.Number == 0 {
+		return nil
+	}
+
+	// Split the text into its parts:
+	//   derivation; definition:--KJV usage.  Notes
+	text := strings.Join(body, " ")
+	if ix := strings.Index(text, ":--"); ix >= 0 {
+		entry.KJVUsage = text[ix+3:]
+		text = text[:ix]
+		if dotIx := strings.Index(entry.KJVUsage, ". "); dotIx >= 0 {
+			entry.Notes = strings.TrimSpace(entry.KJVUsage[dotIx+1:])
+			entry.KJVUsage = entry.KJVUsage[:dotIx]
+		}
+		entry.KJVUsage = strings.TrimSuffix(strings.TrimSpace(entry.KJVUsage), ".")
+	}
+	if ix := strings.Index(text, ";"); ix >= 0 {
+		entry.Derivation = strings.TrimSpace(text[:ix])
+		text = text[ix+1:]
+	}
+	entry.Definition = strings.TrimSpace(text)
 
+	// Numbers in the derivation and definition refer to the same language
+	// unless a "see" line says otherwise
+	prefix := entry.Key()[:1]
+	seen := map[string]bool{}
+	for _, ref := range seeRefs {
+		if !seen[ref] {
+			seen[ref] = true
+			entry.CrossRefs = append(entry.CrossRefs, ref)
 		}
-		close(c)
-	}()
+	}
+	for _, numberString := range wordNumberRegex.FindAllString(entry.Derivation+" "+entry.Definition, -1) {
+		number, _ := strconv.Atoi(numberString)
+		ref := prefix + strconv.Itoa(number)
+		if number > 0 && !seeNumbers[number] && !seen[ref] {
+			seen[ref] = true
+			entry.CrossRefs = append(entry.CrossRefs, ref)
+		}
+	}
 
-	return c, nil
+	return entry
 }
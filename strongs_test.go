@@ -0,0 +1,57 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseStrongsEntry(t *testing.T) {
+	lines := strings.Split(`\04982\
+ 4982  sozo  sode'-zo
+
+ from a primary sos (contraction for obsolete saos, "safe"); to save, i.e.
+ deliver or protect (literally or figuratively):--heal, preserve, save
+ (self), do well, be (make) whole.
+ see GREEK for 4991`, "\n")
+
+	entry := parseStrongsEntry(lines, false)
+	if entry == nil {
+		t.Fatal("parseStrongsEntry returned nil")
+	}
+	if entry.Key() != "g4982" || entry.Lemma != "sozo" || entry.Pronunciation != "sode'-zo" {
+		t.Errorf("header parsed as %s %q %q", entry.Key(), entry.Lemma, entry.Pronunciation)
+	}
+	if want := `from a primary sos (contraction for obsolete saos, "safe")`; entry.Derivation != want {
+		t.Errorf("Derivation = %q, want %q", entry.Derivation, want)
+	}
+	if want := "to save, i.e. deliver or protect (literally or figuratively)"; entry.Definition != want {
+		t.Errorf("Definition = %q, want %q", entry.Definition, want)
+	}
+	if want := "heal, preserve, save (self), do well, be (make) whole"; entry.KJVUsage != want {
+		t.Errorf("KJVUsage = %q, want %q", entry.KJVUsage, want)
+	}
+	if len(entry.CrossRefs) != 1 || entry.CrossRefs[0] != "g4991" {
+		t.Errorf("CrossRefs = %v, want [g4991]", entry.CrossRefs)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Non-interactive subcommands so the tool can be scripted from a shell or cron.
+// Each subcommand runs the same code as its interactive counterpart.
+//
+//   biblestudy verse "Rom 8:28"
+//   biblestudy strongs g4982
+//   biblestudy search rabble
+//   biblestudy translate "Rom 8:1-4"
+//
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Exit codes returned by runSubcommand
+const (
+	exitOK       = 0
+	exitError    = 1 // the lookup failed (network, file, parse error, etc.)
+	exitUsage    = 2 // the subcommand or its arguments are invalid
+	exitNotFound = 3 // the lookup worked, but nothing was found
+)
+
+var (
+	// errNotFound is returned when a lookup succeeds but finds nothing
+	errNotFound = errors.New("not found")
+
+	// errUsage is returned when the input is not something we understand
+	errUsage = errors.New("invalid usage")
+
+	strongsNumberRegex = regexp.MustCompile(`^[gh]\d+$`)
+)
+
+// runSubcommand runs the subcommand named in args[0] with the remaining args
+// and returns the process exit code.
+func runSubcommand(args []string) int {
+	command := strings.ToLower(args[0])
+	text := strings.TrimSpace(strings.Join(args[1:], " "))
+
+	var err error
+	switch command {
+	case "verse", "v", "show":
+		if len(text) == 0 {
+			return usage("verse requires a verse reference")
+		}
+		err = showVerse(text)
+	case "strongs":
+		text = strings.ToLower(text)
+		if strongsWordSearchRegex.MatchString(text) {
+			err = searchStrongsWord(text)
+		} else if strongsNumberRegex.MatchString(text) {
+			err = displayStrongs(text)
+		} else {
+			return usage("strongs requires a strongs number e.g. g4982 or h3068")
+		}
+	case "related":
+		text = strings.ToLower(text)
+		if !strongsNumberRegex.MatchString(text) {
+			return usage("related requires a strongs number e.g. g4982 or h3068")
+		}
+		err = displayRelatedStrongs(text)
+	case "strongsfor", "sf":
+		if len(text) == 0 {
+			return usage("strongsfor requires an English word e.g. love")
+		}
+		err = strongsFor(text)
+	case "xrefs", "x":
+		if len(text) == 0 {
+			return usage("xrefs requires a verse reference")
+		}
+		err = crossReferences(splitCrossRefArgs(text))
+	case "concordance", "cc":
+		text = strings.ToLower(text)
+		if !strongsNumberRegex.MatchString(text) {
+			return usage("concordance requires a strongs number e.g. g26 or h3068")
+		}
+		err = concordance(text)
+	case "search":
+		if len(text) == 0 {
+			return usage("search requires one or more words")
+		}
+		err = displaySearchResults(text)
+	case "translate", "t":
+		morph := false
+		if words := strings.Fields(text); len(words) > 0 && strings.ToLower(words[0]) == "morph" {
+			morph = true
+			text = strings.Join(words[1:], " ")
+		}
+		if len(text) == 0 {
+			return usage("translate requires a verse or passage reference")
+		}
+		err = translate(text, morph)
+	case "morph":
+		err = runMorph(text)
+	case "proverb", "p":
+		_, err = randomProverb()
+	case "declaration", "d":
+		err = runDeclarationCommand(text)
+	case "random":
+		_, err = randomFiltered(text)
+	case "votd":
+		_, err = runVotd(args[1:])
+	case "review":
+		err = runReview(text)
+	case "import":
+		err = importTextFile(args[1:])
+	case "help", "-h", "--help":
+		printHelpSubcommands()
+		return exitOK
+	default:
+		return usage("Unknown subcommand: " + args[0])
+	}
+
+	return exitCode(err)
+}
+
+// exitCode converts the error returned by a command into a process exit code
+func exitCode(err error) int {
+	switch errors.Cause(err) {
+	case nil:
+		return exitOK
+	case errNotFound:
+		return exitNotFound
+	case errUsage:
+		return exitUsage
+	default:
+		return exitError
+	}
+}
+
+// usage displays the message and the subcommand help, then returns exitUsage
+func usage(message string) int {
+	displayErrorText(message)
+	printHelpSubcommands()
+	return exitUsage
+}
+
+func printHelpSubcommands() {
+	fmt.Fprintln(os.Stderr, "Usage: biblestudy [flags] [subcommand [arguments]]")
+	fmt.Fprintln(os.Stderr, "With no subcommand an interactive prompt is started.  Use -h to list the flags.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Subcommands:")
+	fmt.Fprintln(os.Stderr, `  verse "Rom 8:28"          - show the text of a verse or passage`)
+	fmt.Fprintln(os.Stderr, `  translate "Rom 8:1-4"     - show the words of each verse with strongs numbers`)
+	fmt.Fprintln(os.Stderr, `  translate morph "Rom 8:28" - also show the morphology of each Greek or Hebrew word`)
+	fmt.Fprintln(os.Stderr, `  morph [download]          - list or download the tagged Greek and Hebrew files`)
+	fmt.Fprintln(os.Stderr, `  strongs g4982             - show the definition of a strongs number`)
+	fmt.Fprintln(os.Stderr, `  strongs g4982 search nt   - show verses that use a strongs number`)
+	fmt.Fprintln(os.Stderr, `  related g4982             - list the entries related to a strongs number`)
+	fmt.Fprintln(os.Stderr, `  strongsfor love           - list the strongs numbers translated with an English word`)
+	fmt.Fprintln(os.Stderr, `  xrefs "Rom 8:28" 20 in nt  - list the cross references of a passage by votes (add show for the text)`)
+	fmt.Fprintln(os.Stderr, `  concordance g26           - list every English rendering of a strongs number`)
+	fmt.Fprintln(os.Stderr, `  search rabble             - search for verses with the given words`)
+	fmt.Fprintln(os.Stderr, `  proverb                   - show a random proverb`)
+	fmt.Fprintln(os.Stderr, `  random nt gospel          - show a random verse from the matching books`)
+	fmt.Fprintln(os.Stderr, `  votd [--ref] [2020-12-25] - show the verse of the day, or only its reference`)
+	fmt.Fprintln(os.Stderr, `  declaration               - show a random declaration`)
+	fmt.Fprintln(os.Stderr, `  declaration fear          - show a random declaration tagged fear`)
+	fmt.Fprintln(os.Stderr, `  declaration list          - list the declarations (also add, edit, tag, delete, validate)`)
+	fmt.Fprintln(os.Stderr, `  review [declarations|verses] - review the declarations and memory verses that are due`)
+	fmt.Fprintln(os.Stderr, `  import kjv.xml [KJV]      - import an OSIS, USFX or Zefania file for offline use`)
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Exit status is 0 on success, 1 on error, 2 for invalid usage and 3 when nothing is found.")
+}
@@ -0,0 +1,400 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Imports a public domain translation (KJV, WEB, BSB, etc.) from an OSIS,
+// USFX or Zefania XML file into the local text file so that verses can be
+// looked up without a network connection.
+//
+// The format is detected from the root element of the XML file:
+//   <osis>     - OSIS, i.e. <verse osisID="Gen.1.1">...</verse>
+//   <usfx>     - USFX, i.e. <book id="GEN"><c id="1"/><v id="1"/>...<ve/>
+//   <XMLBIBLE> - Zefania, i.e. <BIBLEBOOK bnumber="1"><CHAPTER cnumber="1"><VERS vnumber="1">
+//
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// osisBookIDs are the OSIS book names in the same order as books
+	osisBookIDs = []string{
+		"Gen", "Exod", "Lev", "Num", "Deut", "Josh", "Judg", "Ruth", "1Sam", "2Sam",
+		"1Kgs", "2Kgs", "1Chr", "2Chr", "Ezra", "Neh", "Esth", "Job", "Ps", "Prov",
+		"Eccl", "Song", "Isa", "Jer", "Lam", "Ezek", "Dan", "Hos", "Joel", "Amos",
+		"Obad", "Jonah", "Mic", "Nah", "Hab", "Zeph", "Hag", "Zech", "Mal",
+		"Matt", "Mark", "Luke", "John", "Acts", "Rom", "1Cor", "2Cor", "Gal", "Eph",
+		"Phil", "Col", "1Thess", "2Thess", "1Tim", "2Tim", "Titus", "Phlm", "Heb", "Jas",
+		"1Pet", "2Pet", "1John", "2John", "3John", "Jude", "Rev",
+	}
+
+	// usfmBookIDs are the USFM book codes (used by USFX) in the same order as books
+	usfmBookIDs = []string{
+		"GEN", "EXO", "LEV", "NUM", "DEU", "JOS", "JDG", "RUT", "1SA", "2SA",
+		"1KI", "2KI", "1CH", "2CH", "EZR", "NEH", "EST", "JOB", "PSA", "PRO",
+		"ECC", "SNG", "ISA", "JER", "LAM", "EZK", "DAN", "HOS", "JOL", "AMO",
+		"OBA", "JON", "MIC", "NAM", "HAB", "ZEP", "HAG", "ZEC", "MAL",
+		"MAT", "MRK", "LUK", "JHN", "ACT", "ROM", "1CO", "2CO", "GAL", "EPH",
+		"PHP", "COL", "1TH", "2TH", "1TI", "2TI", "TIT", "PHM", "HEB", "JAS",
+		"1PE", "2PE", "1JN", "2JN", "3JN", "JUD", "REV",
+	}
+
+	// usfxSkipElements hold notes and headings, not verse text
+	usfxSkipElements = map[string]bool{
+		"f": true, "fe": true, "x": true, "rem": true, "h": true, "toc": true,
+		"s": true, "d": true, "fig": true, "cl": true, "cp": true, "ca": true,
+		"va": true, "vp": true,
+	}
+)
+
+// emitVerseFunc receives each verse found by an importer.  bookIx is the
+// index into books.
+type emitVerseFunc func(bookIx, chapter, verse int, text string)
+
+// importTextFile imports the file named by the first argument, optionally
+// with the translation name as the second argument.  The text provider is
+// not changed; the local text is used once it is chosen.
+func importTextFile(args []string) error {
+	if len(args) == 0 || len(args) > 2 {
+		displayErrorText("Expected a file name and an optional translation name.  i.e. import kjv.xml KJV")
+		return errUsage
+	}
+
+	inputFile := args[0]
+	if strings.HasPrefix(inputFile, "~/") {
+		home, _ := os.UserHomeDir()
+		inputFile = filepath.Join(home, inputFile[2:])
+	}
+	var name string
+	if len(args) == 2 {
+		name = args[1]
+	}
+
+	count, err := importText(inputFile, name)
+	if err != nil {
+		displayError("Error importing "+inputFile, err)
+		return err
+	}
+
+	imported := newLocalProvider(filepath.Join(dataDirPath, localTextFileName))
+	fmt.Printf("Imported %d verses of the %s into %s\n", count, imported.Name(), dataDirPath)
+	theme.Hint.Println("Enter provider local to use it, or set text_provider = \"local\" in the config file")
+	return nil
+}
+
+// importText converts the XML file into the local text file and returns the
+// number of verses imported.  The translation name is taken from the file
+// when it is not given.
+func importText(inputFile, name string) (int, error) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	outputFile := filepath.Join(dataDirPath, localTextFileName)
+	out, err := os.Create(outputFile + ".tmp")
+	if err != nil {
+		return 0, err
+	}
+	writer := bufio.NewWriter(out)
+
+	count := 0
+	emit := func(bookIx, chapter, verse int, text string) {
+		text = strings.Join(strings.Fields(text), " ")
+		if bookIx < 0 || chapter == 0 || verse == 0 || len(text) == 0 {
+			return
+		}
+		fmt.Fprintf(writer, "%s %d:%d\t%s\n", books[bookIx].TranslationName, chapter, verse, text)
+		count++
+	}
+
+	decoder := xml.NewDecoder(bufio.NewReader(file))
+	decoder.Strict = false
+	root, err := firstElement(decoder)
+	if err == nil {
+		if len(name) == 0 {
+			name = translationName(inputFile)
+		}
+		fmt.Fprintf(writer, "# %s\n", name)
+
+		switch strings.ToLower(root.Name.Local) {
+		case "osis":
+			err = importOSIS(decoder, emit)
+		case "usfx":
+			err = importUSFX(decoder, emit)
+		case "xmlbible":
+			err = importZefania(decoder, emit)
+		default:
+			err = errors.Errorf("Unknown file format with root element <%s>.  Expected OSIS, USFX or Zefania XML.", root.Name.Local)
+		}
+	}
+	if err == nil && count == 0 {
+		err = errors.New("No verses found in " + inputFile)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	// Close the file without defer so it can happen before Rename()
+	out.Close()
+	if err != nil {
+		os.Remove(outputFile + ".tmp")
+		return 0, err
+	}
+
+	return count, os.Rename(outputFile+".tmp", outputFile)
+}
+
+// importOSIS reads verses from an OSIS document.  Verses may either contain
+// their text or be milestones (sID and eID) around it.
+func importOSIS(decoder *xml.Decoder, emit emitVerseFunc) error {
+	var text strings.Builder
+	var osisID string
+	inVerse, milestone := false, false
+	skipDepth := 0
+
+	finishVerse := func() {
+		if inVerse {
+			bookIx, chapter, verse := parseOSISRef(osisID, osisBookIDs)
+			emit(bookIx, chapter, verse, text.String())
+		}
+		inVerse = false
+		text.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "Error reading OSIS file")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 || t.Name.Local == "note" {
+				skipDepth++
+			} else if t.Name.Local == "verse" {
+				if len(attr(t, "eID")) > 0 {
+					finishVerse()
+				} else if id := attr(t, "osisID"); len(id) > 0 {
+					finishVerse()
+					inVerse = true
+					milestone = len(attr(t, "sID")) > 0
+					osisID = strings.Fields(id)[0]
+				}
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+			} else if t.Name.Local == "verse" && !milestone {
+				finishVerse()
+			}
+		case xml.CharData:
+			if inVerse && skipDepth == 0 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// importUSFX reads verses from a USFX document where each verse starts with
+// a <v id="1"/> milestone and ends with <ve/> or the start of the next verse.
+func importUSFX(decoder *xml.Decoder, emit emitVerseFunc) error {
+	var text strings.Builder
+	bookIx, chapter, verse := -1, 0, 0
+	skipDepth := 0
+
+	finishVerse := func() {
+		if verse > 0 {
+			emit(bookIx, chapter, verse, text.String())
+		}
+		verse = 0
+		text.Reset()
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			finishVerse()
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "Error reading USFX file")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 || usfxSkipElements[t.Name.Local] {
+				skipDepth++
+				continue
+			}
+			switch t.Name.Local {
+			case "book":
+				finishVerse()
+				bookIx = indexOf(usfmBookIDs, strings.ToUpper(attr(t, "id")))
+				chapter = 0
+			case "c":
+				finishVerse()
+				chapter = leadingInt(attr(t, "id"))
+			case "v":
+				finishVerse()
+				verse = leadingInt(attr(t, "id"))
+			case "ve":
+				finishVerse()
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+			} else if t.Name.Local == "book" {
+				finishVerse()
+			}
+		case xml.CharData:
+			if verse > 0 && skipDepth == 0 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// importZefania reads verses from a Zefania XML document
+func importZefania(decoder *xml.Decoder, emit emitVerseFunc) error {
+	var text strings.Builder
+	bookIx, chapter, verse := -1, 0, 0
+	skipDepth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "Error reading Zefania file")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToUpper(t.Name.Local)
+			if skipDepth > 0 || name == "NOTE" || name == "XREF" {
+				skipDepth++
+				continue
+			}
+			switch name {
+			case "BIBLEBOOK":
+				bookIx = leadingInt(attr(t, "bnumber")) - 1
+				if bookIx >= len(books) {
+					// Apocrypha and other books we don't know about
+					bookIx = -1
+				}
+			case "CHAPTER":
+				chapter = leadingInt(attr(t, "cnumber"))
+			case "VERS":
+				verse = leadingInt(attr(t, "vnumber"))
+				text.Reset()
+			}
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+			} else if strings.ToUpper(t.Name.Local) == "VERS" {
+				emit(bookIx, chapter, verse, text.String())
+				verse = 0
+			}
+		case xml.CharData:
+			if verse > 0 && skipDepth == 0 {
+				text.Write(t)
+			}
+		}
+	}
+}
+
+// firstElement returns the root element of the XML document
+func firstElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, errors.Wrap(err, "Error reading XML file")
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// translationName picks a name for the translation from the input file name.
+// i.e. kjv.osis.xml is KJV
+func translationName(inputFile string) string {
+	base := filepath.Base(inputFile)
+	if ix := strings.Index(base, "."); ix > 0 {
+		base = base[:ix]
+	}
+	return strings.ToUpper(base)
+}
+
+// parseOSISRef converts an OSIS reference like "Gen.1.1" into the index into
+// books, the chapter and the verse.  bookIx is -1 if the book is not known.
+func parseOSISRef(osisRef string, bookIDs []string) (bookIx, chapter, verse int) {
+	parts := strings.Split(osisRef, ".")
+	if len(parts) < 3 {
+		return -1, 0, 0
+	}
+	return indexOf(bookIDs, parts[0]), leadingInt(parts[1]), leadingInt(parts[2])
+}
+
+// attr returns the value of the named attribute or an empty string
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// indexOf returns the index of value in values or -1
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// leadingInt converts the digits at the start of the string to an int.
+// i.e. "12-13" is 12.  Zero is returned if there are none.
+func leadingInt(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	i, _ := strconv.Atoi(s[:end])
+	return i
+}
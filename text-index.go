@@ -0,0 +1,268 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// An inverted index of the local text file so that it can be searched
+// without the ESV API.  Like the translation index, it is built the first
+// time it is needed, saved in the data directory and rebuilt whenever the
+// local text file changes.  See text-query.go for the search syntax.
+//
+
+import (
+	"bufio"
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	textIndexFileName = "bible-text.idx"
+
+	// minStemLength keeps short words like "was" and "is" from being
+	// stemmed to nothing
+	minStemLength = 3
+)
+
+// stemSuffixes are replaced at the end of a word to find its stem, longest
+// first.  This is a simple stemmer for English Bibles, not a full Porter
+// stemmer.  i.e. "loveth", "loved", "loving" and "loves" all become "lov".
+var stemSuffixes = [][2]string{
+	{"ingly", ""}, {"edly", ""}, {"ness", ""}, {"ies", "y"}, {"ied", "y"},
+	{"eth", ""}, {"est", ""}, {"ing", ""}, {"ed", ""}, {"es", ""}, {"ly", ""},
+	{"s", ""}, {"e", ""},
+}
+
+// textIndex lists where each word is used in the local text
+type textIndex struct {
+	// SourceSize and SourceModTime identify the version of the local text
+	// file the index was built from
+	SourceSize    int64
+	SourceModTime int64
+
+	// Postings lists the verses and positions of each lower case word, in
+	// the order of the verses
+	Postings map[string][]textPosting
+
+	// words is every word, sorted for prefix searches
+	words []string
+
+	// stems lists the words that have each stem
+	stems map[string][]string
+}
+
+// textPosting is where one word is used in one verse
+type textPosting struct {
+	Verse     int32   // the index into localProvider.verses
+	Positions []int32 // the positions of the word in the verse, from 0
+}
+
+// textToken is a word in a verse and its byte offsets in the text
+type textToken struct {
+	word       string
+	start, end int
+}
+
+// tokenize splits the text into lower case words.  Apostrophes inside a word
+// are kept, but a possessive "'s" is removed.  i.e. "LORD'S" becomes "lord"
+func tokenize(text string) []textToken {
+	var tokens []textToken
+	start := -1
+	runes := []rune(text)
+	offset := 0
+	offsets := make([]int, len(runes)+1)
+	for i, r := range runes {
+		offsets[i] = offset
+		offset += len(string(r))
+	}
+	offsets[len(runes)] = offset
+
+	isWordRune := func(i int) bool {
+		r := runes[i]
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+		// An apostrophe between two letters
+		return (r == '\'' || r == '’') && i > 0 && i+1 < len(runes) &&
+			unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+	}
+
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && isWordRune(i) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			word := strings.ToLower(string(runes[start:i]))
+			word = strings.TrimSuffix(strings.TrimSuffix(word, "'s"), "’s")
+			tokens = append(tokens, textToken{word, offsets[start], offsets[i]})
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// stem returns the stem of a lower case word
+func stem(word string) string {
+	for _, rule := range stemSuffixes {
+		suffix, replacement := rule[0], rule[1]
+		if !strings.HasSuffix(word, suffix) || len(word)-len(suffix) < minStemLength ||
+			(suffix == "s" && strings.HasSuffix(word, "ss")) {
+			continue
+		}
+		s := word[:len(word)-len(suffix)] + replacement
+
+		// "sinned" and "sin" have the same stem, but "called" is "call"
+		if n := len(s); s[n-1] == s[n-2] && !strings.ContainsRune("lsfz", rune(s[n-1])) {
+			s = s[:n-1]
+		}
+		return s
+	}
+	return word
+}
+
+// searchIndex returns the index of the local text, loading or building it
+// the first time
+func (p *localProvider) searchIndex() (*textIndex, error) {
+	if p.index != nil {
+		return p.index, nil
+	}
+
+	info, err := os.Stat(p.fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	indexFile := filepath.Join(dataDirPath, textIndexFileName)
+	index, err := readTextIndex(indexFile)
+	if err != nil || !index.isCurrent(info) {
+		debug("Building text index %s\n", indexFile)
+		index = buildTextIndex(p.verses, info)
+		if err := writeTextIndex(indexFile, index); err != nil {
+			// We can still use the index, it just won't be saved for next time
+			displayError("Error saving text index", err)
+		}
+	}
+
+	index.prepare()
+	p.index = index
+	return index, nil
+}
+
+// isCurrent returns true if the index was built from the given version of
+// the local text file
+func (index *textIndex) isCurrent(info os.FileInfo) bool {
+	return index.SourceSize == info.Size() && index.SourceModTime == info.ModTime().UnixNano()
+}
+
+// buildTextIndex adds the position of every word of every verse
+func buildTextIndex(verses []localVerse, info os.FileInfo) *textIndex {
+	index := &textIndex{
+		SourceSize:    info.Size(),
+		SourceModTime: info.ModTime().UnixNano(),
+		Postings:      make(map[string][]textPosting),
+	}
+
+	for verseIx, v := range verses {
+		positions := map[string][]int32{}
+		var order []string
+		for position, token := range tokenize(v.Text) {
+			if _, ok := positions[token.word]; !ok {
+				order = append(order, token.word)
+			}
+			positions[token.word] = append(positions[token.word], int32(position))
+		}
+		for _, word := range order {
+			index.Postings[word] = append(index.Postings[word], textPosting{int32(verseIx), positions[word]})
+		}
+	}
+
+	debug("Indexed %d verses and %d words\n", len(verses), len(index.Postings))
+	return index
+}
+
+// prepare builds the sorted word list and the stems after loading
+func (index *textIndex) prepare() {
+	index.words = make([]string, 0, len(index.Postings))
+	index.stems = make(map[string][]string)
+	for word := range index.Postings {
+		index.words = append(index.words, word)
+	}
+	sort.Strings(index.words)
+	for _, word := range index.words {
+		s := stem(word)
+		index.stems[s] = append(index.stems[s], word)
+	}
+}
+
+// wordsWithPrefix returns every word that starts with the prefix
+func (index *textIndex) wordsWithPrefix(prefix string) []string {
+	i := sort.SearchStrings(index.words, prefix)
+	var words []string
+	for ; i < len(index.words) && strings.HasPrefix(index.words[i], prefix); i++ {
+		words = append(words, index.words[i])
+	}
+	return words
+}
+
+// readTextIndex decodes the index file
+func readTextIndex(indexFile string) (*textIndex, error) {
+	file, err := os.Open(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index := &textIndex{}
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// writeTextIndex encodes the index to a temporary file then renames it so a
+// partial index is never left behind
+func writeTextIndex(indexFile string, index *textIndex) error {
+	file, err := os.Create(indexFile + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	err = gob.NewEncoder(writer).Encode(index)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	// Close the file without defer so it can happen before Rename()
+	file.Close()
+	if err != nil {
+		os.Remove(indexFile + ".tmp")
+		return err
+	}
+	return os.Rename(indexFile+".tmp", indexFile)
+}
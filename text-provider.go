@@ -0,0 +1,91 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// A TextProvider supplies the Bible text for every command.  The ESV API is
+// used unless the local text file is chosen with the text_provider setting,
+// because the strongs numbers of the translation map file line up with the
+// ESV words.
+//
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	localTextFileName = "bible-text.txt"
+)
+
+// textProvider is used by every command that needs Bible text
+var textProvider TextProvider
+
+// TextProvider looks up and searches Bible text
+type TextProvider interface {
+	// Name is the short name of the translation (i.e. ESV)
+	Name() string
+
+	// Passage returns the text of one or more passages
+	Passage(refs []Reference, options PassageOptions) (*Passage, error)
+
+	// Search returns the verses that contain the given words
+	Search(searchString string) (*SearchResults, error)
+}
+
+// PassageOptions controls the formatting of the passage text
+type PassageOptions struct {
+	LineLength          int // zero means no wrapping
+	IncludeHeadings     bool
+	IncludeFootnotes    bool
+	IndentPoetry        bool
+	IncludeVerseNumbers bool
+}
+
+// selectTextProvider returns the provider named by the text_provider setting,
+// or the ESV API if there is none
+func selectTextProvider() (TextProvider, error) {
+	if len(config.TextProvider) > 0 {
+		err := switchTextProvider(config.TextProvider)
+		return textProvider, err
+	}
+	return newESVProvider(config.ESVApiToken), nil
+}
+
+// switchTextProvider changes the text provider by name (esv or local)
+func switchTextProvider(name string) error {
+	switch strings.ToLower(name) {
+	case "esv":
+		textProvider = newESVProvider(config.ESVApiToken)
+	case "local":
+		localFile := filepath.Join(dataDirPath, localTextFileName)
+		if exists, _ := Exists(localFile); !exists {
+			return errors.Errorf("No local text file found at %s", localFile)
+		}
+		textProvider = newLocalProvider(localFile)
+	default:
+		return errors.Wrap(errUsage, "Unknown text provider "+name+" (expected esv or local)")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectTextProviderIgnoresLocalFile(t *testing.T) {
+	dir := useDataDir(t)
+	if err := WriteLinesAtomic(filepath.Join(dir, localTextFileName), []string{"# KJV", "Rom 8:28\tAnd we know"}); err != nil {
+		t.Fatal(err)
+	}
+	saved, savedProvider := config, textProvider
+	defer func() { config, textProvider = saved, savedProvider }()
+
+	config.TextProvider = ""
+	provider, err := selectTextProvider()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "ESV" {
+		t.Errorf("selectTextProvider chose %s without the text_provider setting", provider.Name())
+	}
+
+	config.TextProvider = "local"
+	if provider, err = selectTextProvider(); err != nil {
+		t.Fatal(err)
+	}
+	if provider.Name() != "KJV" {
+		t.Errorf("selectTextProvider chose %s, want the local KJV", provider.Name())
+	}
+}
@@ -0,0 +1,347 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Searches the local text index.  Words must all be in a verse unless
+// joined by OR.  The operators are upper case, so fear not (without quotes)
+// finds verses with both words.
+//
+//	love fear                both words
+//	love OR fear             either word
+//	love NOT fear            love but not fear (also love -fear)
+//	"fear not"               the exact phrase
+//	love NEAR/5 fear         love within 5 words of fear
+//	lov*                     any word starting with lov
+//	(love OR mercy) truth    parentheses group words
+//
+// Words match other forms with the same stem, so "love" also finds "loveth"
+// and "loved".  Phrases and wildcards match the words as they are.
+//
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var nearRegex = regexp.MustCompile(`^NEAR/(\d+)$`)
+
+// QueryError explains why a search query could not be understood
+type QueryError struct {
+	Message string
+}
+
+func (e *QueryError) Error() string {
+	return e.Message
+}
+
+// Cause lets errors.Cause find errUsage
+func (e *QueryError) Cause() error {
+	return errUsage
+}
+
+// textMatches maps the index of each matching verse to the positions of the
+// matching words in the verse
+type textMatches map[int32][]int32
+
+// queryToken is one part of a search query
+type queryToken struct {
+	kind string // "word", "phrase", "(", ")", "and", "or", "not" or "near"
+	text string
+	near int
+}
+
+// textQuery parses and evaluates a search query against the index
+type textQuery struct {
+	index     *textIndex
+	numVerses int
+	tokens    []queryToken
+	pos       int
+}
+
+// searchTextIndex returns the verses matching the query
+func searchTextIndex(index *textIndex, numVerses int, query string) (textMatches, error) {
+	q := &textQuery{index: index, numVerses: numVerses, tokens: lexQuery(query)}
+	if len(q.tokens) == 0 {
+		return nil, &QueryError{"Enter one or more words to search for"}
+	}
+	matches, err := q.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if q.pos < len(q.tokens) {
+		return nil, &QueryError{"Unexpected " + q.tokens[q.pos].text + " in search"}
+	}
+	return matches, nil
+}
+
+// lexQuery splits the query into words, phrases, parentheses and operators
+func lexQuery(query string) []queryToken {
+	var tokens []queryToken
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, queryToken{kind: string(c), text: string(c)})
+			i++
+		case c == '"':
+			end := strings.IndexByte(query[i+1:], '"')
+			if end < 0 {
+				end = len(query) - i - 1
+			}
+			tokens = append(tokens, queryToken{kind: "phrase", text: query[i+1 : i+1+end]})
+			i += end + 2
+		default:
+			end := strings.IndexAny(query[i:], " \t()\"")
+			if end < 0 {
+				end = len(query) - i
+			}
+			tokens = append(tokens, wordToken(query[i:i+end])...)
+			i += end
+		}
+	}
+	return tokens
+}
+
+// wordToken returns the tokens for a word, which may be an upper case
+// operator
+func wordToken(word string) []queryToken {
+	switch {
+	case word == "AND" || word == "OR" || word == "NOT":
+		return []queryToken{{kind: strings.ToLower(word), text: word}}
+	case nearRegex.MatchString(word):
+		n, _ := strconv.Atoi(nearRegex.FindStringSubmatch(word)[1])
+		return []queryToken{{kind: "near", text: word, near: n}}
+	case strings.HasPrefix(word, "-") && len(word) > 1:
+		return []queryToken{{kind: "not", text: "-"}, {kind: "word", text: word[1:]}}
+	default:
+		return []queryToken{{kind: "word", text: word}}
+	}
+}
+
+// next returns the kind of the next token, or "" at the end
+func (q *textQuery) next() string {
+	if q.pos < len(q.tokens) {
+		return q.tokens[q.pos].kind
+	}
+	return ""
+}
+
+// parseOr parses words joined by OR, which binds least tightly
+func (q *textQuery) parseOr() (textMatches, error) {
+	left, err := q.parseAnd()
+	for err == nil && q.next() == "or" {
+		q.pos++
+		var right textMatches
+		if right, err = q.parseAnd(); err == nil {
+			left = unionMatches(left, right)
+		}
+	}
+	return left, err
+}
+
+// parseAnd parses words joined by AND, or by nothing at all
+func (q *textQuery) parseAnd() (textMatches, error) {
+	left, err := q.parseNear()
+	for err == nil {
+		switch q.next() {
+		case "and":
+			q.pos++
+		case "word", "phrase", "(", "not":
+		default:
+			return left, nil
+		}
+		var right textMatches
+		if right, err = q.parseNear(); err == nil {
+			left = intersectMatches(left, right)
+		}
+	}
+	return left, err
+}
+
+// parseNear parses words joined by NEAR/n
+func (q *textQuery) parseNear() (textMatches, error) {
+	left, err := q.parseNot()
+	for err == nil && q.next() == "near" {
+		distance := q.tokens[q.pos].near
+		q.pos++
+		var right textMatches
+		if right, err = q.parseNot(); err == nil {
+			left = nearMatches(left, right, int32(distance))
+		}
+	}
+	return left, err
+}
+
+// parseNot parses NOT, which binds most tightly
+func (q *textQuery) parseNot() (textMatches, error) {
+	if q.next() != "not" {
+		return q.parseTerm()
+	}
+	q.pos++
+	matches, err := q.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	others := textMatches{}
+	for v := int32(0); v < int32(q.numVerses); v++ {
+		if _, ok := matches[v]; !ok {
+			others[v] = nil
+		}
+	}
+	return others, nil
+}
+
+// parseTerm parses a word, a phrase or a group in parentheses
+func (q *textQuery) parseTerm() (textMatches, error) {
+	if q.pos >= len(q.tokens) {
+		return nil, &QueryError{"The search ends too soon"}
+	}
+	token := q.tokens[q.pos]
+	q.pos++
+
+	switch token.kind {
+	case "(":
+		matches, err := q.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if q.next() != ")" {
+			return nil, &QueryError{"Missing ) in search"}
+		}
+		q.pos++
+		return matches, nil
+	case "phrase":
+		return q.index.phraseMatches(tokenize(token.text)), nil
+	case "word":
+		if strings.HasSuffix(token.text, "*") {
+			prefix := strings.ToLower(strings.TrimRight(token.text, "*"))
+			return q.index.wordMatches(q.index.wordsWithPrefix(prefix)), nil
+		}
+		tokens := tokenize(token.text)
+		if len(tokens) != 1 {
+			return q.index.phraseMatches(tokens), nil
+		}
+		return q.index.wordMatches(q.index.stems[stem(tokens[0].word)]), nil
+	default:
+		return nil, &QueryError{"Unexpected " + token.text + " in search"}
+	}
+}
+
+// wordMatches returns the verses using any of the words
+func (index *textIndex) wordMatches(words []string) textMatches {
+	matches := textMatches{}
+	for _, word := range words {
+		for _, posting := range index.Postings[word] {
+			matches[posting.Verse] = append(matches[posting.Verse], posting.Positions...)
+		}
+	}
+	return matches
+}
+
+// phraseMatches returns the verses using the words one after another
+func (index *textIndex) phraseMatches(words []textToken) textMatches {
+	matches := textMatches{}
+	if len(words) == 0 {
+		return matches
+	}
+
+	// The positions of each word of the phrase, keyed by verse
+	wordPositions := make([]map[int32][]int32, len(words))
+	for i, word := range words {
+		wordPositions[i] = map[int32][]int32{}
+		for _, posting := range index.Postings[word.word] {
+			wordPositions[i][posting.Verse] = posting.Positions
+		}
+	}
+
+	for verse, starts := range wordPositions[0] {
+		for _, start := range starts {
+			found := true
+			for i := 1; i < len(words) && found; i++ {
+				found = containsPosition(wordPositions[i][verse], start+int32(i))
+			}
+			if found {
+				for i := range words {
+					matches[verse] = append(matches[verse], start+int32(i))
+				}
+			}
+		}
+	}
+	return matches
+}
+
+// unionMatches returns the verses in either
+func unionMatches(a, b textMatches) textMatches {
+	for verse, positions := range b {
+		a[verse] = append(a[verse], positions...)
+	}
+	return a
+}
+
+// intersectMatches returns the verses in both
+func intersectMatches(a, b textMatches) textMatches {
+	matches := textMatches{}
+	for verse, positions := range a {
+		if other, ok := b[verse]; ok {
+			matches[verse] = append(positions, other...)
+		}
+	}
+	return matches
+}
+
+// nearMatches returns the verses with a word from each within the distance
+func nearMatches(a, b textMatches, distance int32) textMatches {
+	matches := textMatches{}
+	for verse, positions := range a {
+		for _, pa := range positions {
+			for _, pb := range b[verse] {
+				if pa-pb <= distance && pb-pa <= distance {
+					matches[verse] = append(matches[verse], pa, pb)
+				}
+			}
+		}
+	}
+	return matches
+}
+
+func containsPosition(positions []int32, position int32) bool {
+	for _, p := range positions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedVerses returns the matching verse indexes in order
+func (matches textMatches) sortedVerses() []int32 {
+	verses := make([]int32, 0, len(matches))
+	for verse := range matches {
+		verses = append(verses, verse)
+	}
+	sort.Slice(verses, func(i, j int) bool { return verses[i] < verses[j] })
+	return verses
+}
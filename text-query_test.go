@@ -0,0 +1,114 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"love":      "lov",
+		"loved":     "lov",
+		"loveth":    "lov",
+		"loving":    "lov",
+		"loves":     "lov",
+		"sinned":    "sin",
+		"called":    "call",
+		"mercies":   "mercy",
+		"was":       "was",
+		"is":        "is",
+		"kindness":  "kind",
+		"goodness":  "good",
+		"gladly":    "glad",
+		"righteous": "righteou",
+	}
+	for word, want := range tests {
+		if got := stem(word); got != want {
+			t.Errorf("stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestSearchTextIndex(t *testing.T) {
+	verses := []localVerse{
+		{Text: "Fear not, for I am with you."},
+		{Text: "There is no fear in love, but perfect love casts out fear."},
+		{Text: "The LORD loveth the righteous."},
+		{Text: "Be not afraid, neither be thou dismayed."},
+		{Text: "Mercy and truth are met together."},
+		{Text: "Love and faithfulness meet."},
+	}
+	file := filepath.Join(t.TempDir(), "bible-text.txt")
+	if err := ioutil.WriteFile(file, []byte("# TEST\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := buildTextIndex(verses, info)
+	index.prepare()
+
+	tests := []struct {
+		query  string
+		verses []int32
+	}{
+		{"fear", []int32{0, 1}},
+		{"fear not", []int32{0}},
+		{"Fear Not", []int32{0}},
+		{"fear NOT love", []int32{0}},
+		{"fear -love", []int32{0}},
+		{"fear OR afraid", []int32{0, 1, 3}},
+		{"fear AND love", []int32{1}},
+		{`"fear not"`, []int32{0}},
+		{`"not fear"`, nil},
+		{"love", []int32{1, 2, 5}},
+		{"lov*", []int32{1, 2, 5}},
+		{"fear NEAR/2 love", []int32{1}},
+		{"fear NEAR/1 perfect", nil},
+		{"(mercy OR faithfulness) truth", []int32{4}},
+		{"(mercy OR faithfulness) and", []int32{4, 5}},
+	}
+	for _, test := range tests {
+		matches, err := searchTextIndex(index, len(verses), test.query)
+		if err != nil {
+			t.Errorf("searchTextIndex(%q) returned error: %v", test.query, err)
+			continue
+		}
+		if got := matches.sortedVerses(); !reflect.DeepEqual(got, test.verses) && len(got)+len(test.verses) > 0 {
+			t.Errorf("searchTextIndex(%q) found verses %v, want %v", test.query, got, test.verses)
+		}
+	}
+
+	for _, query := range []string{"fear OR", "(fear", "NOT", ")", "FEAR NOT"} {
+		if _, err := searchTextIndex(index, len(verses), query); err == nil {
+			t.Errorf("searchTextIndex(%q) returned no error", query)
+		} else if _, ok := err.(*QueryError); !ok {
+			t.Errorf("searchTextIndex(%q) returned %T, want *QueryError", query, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// Color themes for the output.  Choose one with the color_theme setting.
+//
+
+import (
+	"github.com/gookit/color"
+	"github.com/pkg/errors"
+)
+
+// theme is the color theme in effect
+var theme = themes["default"]
+
+// Theme holds the color for each kind of output
+type Theme struct {
+	Prompt    color.Color // the " > " prompt
+	Hint      color.Color // the commands that can be entered
+	Muted     color.Color // less important text, i.e. the current verse
+	Heading   color.Color // headings, i.e. a strongs number and word
+	Highlight color.Color // the words that matched a search
+	Error     color.Color
+}
+
+var themes = map[string]Theme{
+	// default is for terminals with a dark background
+	"default": {
+		Prompt:    color.Magenta,
+		Hint:      color.Cyan,
+		Muted:     color.FgDarkGray,
+		Heading:   color.Green,
+		Highlight: color.Yellow,
+		Error:     color.Red,
+	},
+	// light is for terminals with a light background
+	"light": {
+		Prompt:    color.Magenta,
+		Hint:      color.Blue,
+		Muted:     color.FgDarkGray,
+		Heading:   color.Magenta,
+		Highlight: color.Bold,
+		Error:     color.Red,
+	},
+	// none turns off color, which is also done by setting NO_COLOR
+	"none": {},
+}
+
+// setTheme changes the color theme by name
+func setTheme(name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return errors.Wrap(errUsage, "Unknown color theme "+name+" (expected default, light or none)")
+	}
+	theme = t
+	if name == "none" {
+		color.Disable()
+	}
+	return nil
+}
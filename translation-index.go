@@ -0,0 +1,267 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// An index of the translation map file (TTESV.txt) so that translations and
+// strongs searches do not have to scan the whole file.  The index is built
+// the first time it is needed, saved in the data directory and rebuilt
+// whenever the translation map file changes.
+//
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	translationIndexFileName = "TTESV.idx"
+)
+
+// translationIdx is loaded lazily by loadTranslationIndex
+var translationIdx *translationIndex
+
+// translationIndex maps verse references to their line in the translation
+// map file and strongs numbers to the verses that use them.
+type translationIndex struct {
+	// SourceSize and SourceModTime identify the version of the translation
+	// map file the index was built from
+	SourceSize    int64
+	SourceModTime int64
+
+	// Offsets is the byte offset of the line for each verse, keyed by
+	// verse reference.  i.e. "Rom 8:28"
+	Offsets map[string]int64
+
+	// Verses lists the verse references that use each strongs number, in
+	// the order of the file, keyed by strongs number.  i.e. "g4982"
+	Verses map[string][]string
+}
+
+// translationMapLine returns the strongs mappings for one verse, without the
+// verse reference.  i.e. for "Rom 8:28" it returns "01=<1161> 03=<1492> ..."
+func translationMapLine(verseRef string) (string, error) {
+	index, err := loadTranslationIndex()
+	if err != nil {
+		return "", err
+	}
+
+	offset, ok := index.Offsets[verseRef]
+	if !ok {
+		return "", errors.Wrap(errNotFound, "No translation map line for "+verseRef)
+	}
+
+	file, err := os.Open(translationMapFile)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	// Remove the "$Rom 8:28<tab>" prefix
+	line = strings.TrimRight(line, "\r\n")
+	return line[strings.Index(line, "\t")+1:], nil
+}
+
+// versesUsingStrongs returns the references of the verses that use the given
+// strongs number (i.e. g4982 or h03068) in the order of the Bible
+func versesUsingStrongs(strongsNum string) ([]string, error) {
+	index, err := loadTranslationIndex()
+	if err != nil {
+		return nil, err
+	}
+	return index.Verses[strongsKey(strongsNum)], nil
+}
+
+// strongsKey normalizes a strongs number by removing leading zeros.
+// i.e. "h03068" becomes "h3068"
+func strongsKey(strongsNum string) string {
+	strongsNum = strings.ToLower(strongsNum)
+	if len(strongsNum) < 2 {
+		return strongsNum
+	}
+	num, err := strconv.Atoi(strongsNum[1:])
+	if err != nil {
+		return strongsNum
+	}
+	return strongsNum[:1] + strconv.Itoa(num)
+}
+
+// loadTranslationIndex reads the index from the data directory, building it
+// first if it is missing or out of date
+func loadTranslationIndex() (*translationIndex, error) {
+	info, err := os.Stat(translationMapFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if translationIdx != nil && translationIdx.isCurrent(info) {
+		return translationIdx, nil
+	}
+
+	indexFile := filepath.Join(dataDirPath, translationIndexFileName)
+	index, err := readTranslationIndex(indexFile)
+	if err != nil || !index.isCurrent(info) {
+		debug("Building translation index %s\n", indexFile)
+		index, err = buildTranslationIndex(translationMapFile, info)
+		if err != nil {
+			return nil, errors.Wrap(err, "Error building translation index")
+		}
+		if err := writeTranslationIndex(indexFile, index); err != nil {
+			// We can still use the index, it just won't be saved for next time
+			displayError("Error saving translation index", err)
+		}
+	}
+
+	translationIdx = index
+	return index, nil
+}
+
+// isCurrent returns true if the index was built from the given version of
+// the translation map file
+func (index *translationIndex) isCurrent(info os.FileInfo) bool {
+	return index.SourceSize == info.Size() && index.SourceModTime == info.ModTime().UnixNano()
+}
+
+// buildTranslationIndex reads every line of the translation map file.
+// Lines look like this:
+//
+//	$Gen 2:11	02=<08034> 05=<00259> 08=<06376> 09=<01931> ...
+func buildTranslationIndex(fileName string, info os.FileInfo) (*translationIndex, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index := &translationIndex{
+		SourceSize:    info.Size(),
+		SourceModTime: info.ModTime().UnixNano(),
+		Offsets:       make(map[string]int64),
+		Verses:        make(map[string][]string),
+	}
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		lineOffset := offset
+		offset += int64(len(line))
+
+		tabIx := strings.Index(line, "\t")
+		if strings.HasPrefix(line, "$") && tabIx > 0 {
+			verseRef := line[1:tabIx]
+			if _, present := index.Offsets[verseRef]; !present {
+				index.Offsets[verseRef] = lineOffset
+				index.addStrongs(verseRef, line[tabIx+1:])
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	debug("Indexed %d verses and %d strongs numbers\n", len(index.Offsets), len(index.Verses))
+	return index, nil
+}
+
+// addStrongs adds the verse to the list of verses for each strongs number in
+// the mappings.  i.e. "01=<3972> 12+13=<2596> 15=<1161>+<2532>"
+func (index *translationIndex) addStrongs(verseRef, mappings string) {
+	prefix := "h"
+	if spaceIx := strings.LastIndex(verseRef, " "); spaceIx > 0 {
+		if book, ok := findBook(verseRef[:spaceIx]); ok && book.Testament == newTestament {
+			prefix = "g"
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, mapping := range strings.Fields(mappings) {
+		eqIx := strings.Index(mapping, "=")
+		if eqIx < 0 {
+			continue
+		}
+		for _, number := range numberRegex.FindAllString(mapping[eqIx+1:], -1) {
+			key := strongsKey(prefix + number)
+			if !seen[key] {
+				seen[key] = true
+				index.Verses[key] = append(index.Verses[key], verseRef)
+			}
+		}
+	}
+}
+
+// readTranslationIndex decodes the index file
+func readTranslationIndex(indexFile string) (*translationIndex, error) {
+	file, err := os.Open(indexFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	index := &translationIndex{}
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// writeTranslationIndex encodes the index to a temporary file then renames
+// it so a partial index is never left behind
+func writeTranslationIndex(indexFile string, index *translationIndex) error {
+	file, err := os.Create(indexFile + ".tmp")
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	err = gob.NewEncoder(writer).Encode(index)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	// Close the file without defer so it can happen before Rename()
+	file.Close()
+	if err != nil {
+		os.Remove(indexFile + ".tmp")
+		return err
+	}
+	return os.Rename(indexFile+".tmp", indexFile)
+}
@@ -27,6 +27,10 @@ import (
 	"strings"
 )
 
+// maxStrongsSearchVerses limits the verses looked up by a subcommand, which
+// shows every page at once
+const maxStrongsSearchVerses = 100
+
 var (
 	strongsWordSearchRegex = regexp.MustCompile(`^([gh]\d+) +search`)
 	numberedBookRegex      = regexp.MustCompile(` ([123]) ([a-z])`)
@@ -60,22 +64,9 @@ func searchStrongsWord(searchString string) error {
 	}
 
 	//
-	// Traverse the translation file looking for verses that use the given strongsWord
-	// The translation file has lines  that look like this:
-	// $Gen 2:11 02=<08034> 05=<00259> 08=<06376> 09=<01931> ...
+	// Use the translation index to find the verses that use the given strongsWord
 	//
-
-	strongsNumber := strongsWord[1:] // Remove the prefix: "g" or "h"
-
-	// Greek Strongs words have 4 digits, Hebrew has 5 digits (left-zero padded)
-	var format = "[<+]%04s[+>]" // greek is the default (4 digits)
-	if strings.HasPrefix(strongsWord, "h") {
-		format = "[<+]%05s[+>]" // switch to 5 digits
-	}
-
-	lookupRegex := regexp.MustCompile(fmt.Sprintf(format, strongsNumber))
-	// Grep the file
-	c, err := grep(translationMapFile, lookupRegex)
+	allVerses, err := versesUsingStrongs(strongsWord)
 	if err != nil {
 		displayErrorText(fmt.Sprintf("Unable to read file: %s, %v\n", translationMapFile, err))
 		return err
@@ -84,7 +75,7 @@ func searchStrongsWord(searchString string) error {
 	var bookPattern *regexp.Regexp
 	if !allBooks {
 		// Pattern is a regular expression that contains all the book names we are allowing in our search
-		patternStr := `^\$(` + strings.Join(*bookNames, "|") + `) `
+		patternStr := `^(` + strings.Join(*bookNames, "|") + `) `
 		bookPattern, err = regexp.Compile(patternStr)
 		if err != nil {
 			displayError("Error compiling regex "+patternStr, err)
@@ -94,32 +85,35 @@ func searchStrongsWord(searchString string) error {
 	}
 
 	var verses []string
-	for line := range c {
-		// If the pattern matches then we can accept this line
-		if allBooks || bookPattern.MatchString(line) {
-			// The verse reference is the first part of the line until the first tab
-			tabIx := strings.Index(line, "\t")
-			verses = append(verses, line[1:tabIx])
+	for _, verseRef := range allVerses {
+		// If the pattern matches then we can accept this verse
+		if allBooks || bookPattern.MatchString(verseRef) {
+			verses = append(verses, verseRef)
 		}
 	}
 
 	debug("Found %d verses %v\n", len(verses), verses)
 
-	if len(verses) > 20 {
-		fmt.Printf("Showing only 20 of %d verses found.\n", len(verses))
-		verses = verses[:19]
+	if len(verses) == 0 {
+		displayErrorText("No verses found")
+		return errNotFound
 	}
 
-	// TODO:  Allow user to page through results
-
-	//
-	// Lookup all the verses that use the given strongs number
-	//
+	fmt.Printf("Found %d verses\n", len(verses))
+	if !interactive && len(verses) > maxStrongsSearchVerses {
+		theme.Muted.Printf("Showing the first %d.  Add books to search fewer, i.e. %s search epistles\n", maxStrongsSearchVerses, strongsWord)
+		verses = verses[:maxStrongsSearchVerses]
+	}
+	return runPager(len(verses), func(start, end int) error {
+		return printVersesOnOneLine(verses[start:end])
+	})
+}
 
-	versesLookupString := strings.Join(verses, " ")
-	//versesLookupString = strings.ReplaceAll(versesLookupString, ":", ".")
-	//versesLookupString = strings.ReplaceAll(versesLookupString, " ", "")
-	fmt.Printf("Verses lookup string: %s\n", versesLookupString)
+// printVersesOnOneLine looks up all the verses in one request and prints
+// each on its own line
+func printVersesOnOneLine(verses []string) error {
+	versesLookupString := strings.Join(verses, "; ")
+	debug("Verses lookup string: %s\n", versesLookupString)
 	passage, err := lookupVerse(versesLookupString, 0,
 		false, /*includeHeadings*/
 		false, /*includeFootnotes*/
@@ -137,16 +131,16 @@ func searchStrongsWord(searchString string) error {
 	//
 
 	found := false
-	newLineRegex := regexp.MustCompile(`[\n]`)
+	copyright := "(" + textProvider.Name() + ")"
 	for _, passageText := range passage.Passages {
 		// Put verse on one line
-		newText := newLineRegex.ReplaceAllString(passageText, " ")
-		newText = strings.ReplaceAll(newText, "(ESV)", "")
+		newText := newlineRegex.ReplaceAllString(passageText, " ")
+		newText = strings.ReplaceAll(newText, copyright, "")
 		fmt.Println(newText)
 		found = true
 	}
 	if found {
-		fmt.Println("(ESV)")
+		fmt.Println(copyright)
 	}
 	fmt.Println()
 
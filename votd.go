@@ -0,0 +1,172 @@
+/*
+Copyright © 2020 Jon Carlson <joncrlsn@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+//
+// The verse of the day is the same for everyone on a given date.  The verses
+// come from votdVerses, or from the file named by the votd_file setting with
+// one reference per line.  Each verse is shown once before any is repeated.
+//
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultVotdName = "votd.txt"
+	votdDateFormat  = "2006-01-02"
+)
+
+// votdEpoch is the first day of the first cycle through the verses
+var votdEpoch = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// votdVerses is the built-in list of verses of the day
+var votdVerses = []string{
+	"Genesis 1:1", "Genesis 50:20", "Exodus 14:14", "Numbers 6:24-26",
+	"Deuteronomy 31:6", "Joshua 1:9", "1 Samuel 16:7", "2 Chronicles 7:14",
+	"Nehemiah 8:10", "Job 19:25", "Psalm 1:1-2", "Psalm 16:11",
+	"Psalm 19:14", "Psalm 23:1", "Psalm 27:1", "Psalm 34:8",
+	"Psalm 37:4", "Psalm 46:10", "Psalm 51:10", "Psalm 73:26",
+	"Psalm 90:12", "Psalm 103:2-3", "Psalm 118:24", "Psalm 119:105",
+	"Psalm 121:1-2", "Psalm 139:14", "Proverbs 3:5-6", "Proverbs 4:23",
+	"Proverbs 16:3", "Proverbs 18:10", "Ecclesiastes 3:1", "Isaiah 26:3",
+	"Isaiah 40:31", "Isaiah 41:10", "Isaiah 43:2", "Isaiah 53:5",
+	"Isaiah 55:8-9", "Jeremiah 29:11", "Jeremiah 31:3", "Lamentations 3:22-23",
+	"Micah 6:8", "Habakkuk 3:17-18", "Zephaniah 3:17", "Matthew 5:14-16",
+	"Matthew 6:33", "Matthew 6:34", "Matthew 11:28-30", "Matthew 28:19-20",
+	"Mark 10:45", "Mark 11:24", "Luke 1:37", "Luke 6:38",
+	"John 1:12", "John 3:16", "John 8:32", "John 10:10",
+	"John 14:6", "John 14:27", "John 15:5", "John 16:33",
+	"Acts 1:8", "Romans 5:8", "Romans 8:1", "Romans 8:28",
+	"Romans 8:38-39", "Romans 12:1-2", "Romans 15:13", "1 Corinthians 10:13",
+	"1 Corinthians 13:4-7", "2 Corinthians 5:17", "2 Corinthians 12:9", "Galatians 2:20",
+	"Galatians 5:22-23", "Ephesians 2:8-10", "Ephesians 3:20", "Ephesians 6:10-11",
+	"Philippians 1:6", "Philippians 4:6-7", "Philippians 4:8", "Philippians 4:13",
+	"Colossians 3:2", "Colossians 3:23", "1 Thessalonians 5:16-18", "2 Timothy 1:7",
+	"2 Timothy 3:16-17", "Hebrews 4:12", "Hebrews 4:16", "Hebrews 11:1",
+	"Hebrews 12:1-2", "Hebrews 13:8", "James 1:2-3", "James 1:5",
+	"James 4:7", "1 Peter 5:7", "2 Peter 3:9", "1 John 1:9",
+	"1 John 4:18", "1 John 4:19", "Revelation 3:20", "Revelation 21:4",
+}
+
+// verseOfTheDay returns the reference of the verse of the day for the date
+func verseOfTheDay(date time.Time) (string, error) {
+	verses, err := loadVotdVerses()
+	if err != nil {
+		return "", err
+	}
+
+	// Shuffle the verses the same way for everyone, with a new shuffle each
+	// time all of them have been shown
+	days := int64(time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).Sub(votdEpoch).Hours() / 24)
+	n := int64(len(verses))
+	cycle, day := days/n, days%n
+	if day < 0 {
+		cycle, day = cycle-1, day+n
+	}
+	order := rand.New(rand.NewSource(cycle)).Perm(len(verses))
+	return verses[order[day]], nil
+}
+
+// runVotd shows the verse of the day.  The arguments may include a date
+// (YYYY-MM-DD), and --ref to print only the reference for a shell prompt.
+func runVotd(args []string) (string, error) {
+	date := time.Now()
+	refOnly := false
+	for _, arg := range args {
+		if arg == "--ref" || arg == "-ref" {
+			refOnly = true
+			continue
+		}
+		d, err := time.ParseInLocation(votdDateFormat, arg, time.Local)
+		if err != nil {
+			displayErrorText("Expected a date like 2020-12-25: " + arg)
+			return "", errUsage
+		}
+		date = d
+	}
+
+	ref, err := verseOfTheDay(date)
+	if err != nil {
+		return "", err
+	}
+	if refOnly {
+		fmt.Println(ref)
+		return ref, nil
+	}
+	theme.Heading.Printf("Verse of the day for %s\n", date.Format("Monday, January 2"))
+	return displayPassage(ref,
+		false, /*includeHeadings*/
+		false, /*includeFootnotes*/
+		true,  /*indentPoetry*/
+		true /*includeVerseNumbers*/)
+}
+
+// loadVotdVerses returns the references in the votd file, or the built-in
+// verses if there is no file
+func loadVotdVerses() ([]string, error) {
+	fileName := config.VotdFile
+	if exists, _ := Exists(fileName); !exists {
+		if fileName != filepath.Join(dataDirPath, defaultVotdName) {
+			displayErrorText("Verse of the day file not found: " + fileName)
+			return nil, errNotFound
+		}
+		return votdVerses, nil
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		displayError("Error reading verse of the day file", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	var verses []string
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		refs, err := ParseReferences(line)
+		if err != nil {
+			displayErrorText(fmt.Sprintf("Skipping line %d of %s: %v", lineNum, fileName, err))
+			continue
+		}
+		verses = append(verses, FormatReferences(refs))
+	}
+	if err := scanner.Err(); err != nil {
+		displayError("Error reading verse of the day file", err)
+		return nil, err
+	}
+	if len(verses) == 0 {
+		displayErrorText("There are no verses in " + fileName)
+		return nil, errNotFound
+	}
+	return verses, nil
+}